@@ -0,0 +1,163 @@
+package jenkins
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"strconv"
+)
+
+const manifestTree string = "artifacts[displayPath,fileName,relativePath],fingerprint[fileName,hash]"
+
+type ArtifactMeta struct {
+	FileName     string
+	RelativePath string
+	DisplayPath  string
+	// -1 when the server does not report a Content-Length
+	Size int64
+	// empty when the artifact was not fingerprinted
+	MD5 string
+}
+
+// ListArtifacts returns the build's artifacts, mapping each display path to
+// the relative path GetArtifactReader fetches it from.
+func (self *Client) ListArtifacts(name string, id int) (map[string]string, error) {
+	info, err := self.GetBuildInfo(name, id)
+	if err != nil || info == nil {
+		return nil, err
+	}
+	return info.Artifacts, nil
+}
+
+// CopyArtifact streams a single artifact to w, e.g. stdout, and returns the
+// number of bytes copied.
+func (self *Client) CopyArtifact(name string, id int, artifact string, w io.Writer) (int64, error) {
+	reader, err := self.GetArtifactReader(name, id, artifact)
+	if err != nil {
+		return 0, err
+	}
+	defer reader.Close()
+	return io.Copy(w, reader)
+}
+
+// DownloadArtifactsZip streams all of the build's artifacts to w as the single
+// zip archive Jenkins bundles server-side, which is much faster than
+// GetArtifacts for builds with many files.
+func (self *Client) DownloadArtifactsZip(name string, id int, w io.Writer) (int64, error) {
+	info, err := self.GetBuildInfo(name, id)
+	if err != nil {
+		return 0, err
+	}
+	if err := self.checkArtifactResult(info); err != nil {
+		return 0, err
+	}
+	nameAndID := path.Join(jobPath(name), strconv.Itoa(info.ID))
+	reader, err := self.getRemote(context.Background(), self.url(nameAndID, "artifact", "*zip*", "archive.zip"))
+	if err != nil {
+		return 0, err
+	}
+	defer reader.Close()
+	return io.Copy(w, reader)
+}
+
+func (self *Client) GetArtifactManifest(name string, id int) ([]ArtifactMeta, error) {
+	id, err := self.sanitizeID(context.Background(), name, id)
+	if err != nil {
+		return nil, err
+	}
+	nameAndID := path.Join(jobPath(name), strconv.Itoa(id))
+	theurl := self.url(nameAndID, "api", "json") + "?tree=" + url.QueryEscape(manifestTree)
+	json, err := self.getJSON(context.Background(), theurl)
+	if err != nil {
+		return nil, err
+	}
+	manifest := []ArtifactMeta{}
+	artifacts, _ := json["artifacts"].([]interface{})
+	relativePaths := []string{}
+	for _, artifact := range artifacts {
+		artifactSafe, _ := artifact.(map[string]interface{})
+		relativePath, _ := artifactSafe["relativePath"].(string)
+		relativePaths = append(relativePaths, relativePath)
+	}
+	hashes := artifactHashes(parseFingerprints(json), relativePaths)
+	for _, artifact := range artifacts {
+		artifactSafe, _ := artifact.(map[string]interface{})
+		meta := ArtifactMeta{}
+		meta.FileName, _ = artifactSafe["fileName"].(string)
+		meta.RelativePath, _ = artifactSafe["relativePath"].(string)
+		meta.DisplayPath, _ = artifactSafe["displayPath"].(string)
+		meta.MD5 = hashes[meta.RelativePath]
+		size, err := self.headSize(context.Background(), self.url(nameAndID, "artifact", meta.RelativePath))
+		if err != nil {
+			return manifest, err
+		}
+		meta.Size = size
+		manifest = append(manifest, meta)
+	}
+	return manifest, nil
+}
+
+const fingerprintsTree string = "fingerprint[fileName,hash]"
+
+// getFingerprints returns the MD5 of each fingerprinted artifact of the build
+// at nameAndID, keyed by file name
+func (self *Client) getFingerprints(ctx context.Context, nameAndID string) (map[string]string, error) {
+	theurl := self.url(nameAndID, "api", "json") + "?tree=" + url.QueryEscape(fingerprintsTree)
+	json, err := self.getJSON(ctx, theurl)
+	if err != nil {
+		return nil, err
+	}
+	return parseFingerprints(json), nil
+}
+
+// artifactHashes keys the fingerprinted MD5s by each artifact's relative path.
+// Jenkins may name a fingerprint after just the file, so that is used as long
+// as no other artifact shares it; otherwise the artifact is left unverified
+// rather than compared against another one's hash.
+func artifactHashes(hashes map[string]string, relativePaths []string) map[string]string {
+	basenames := map[string]int{}
+	for _, relativePath := range relativePaths {
+		basenames[path.Base(relativePath)]++
+	}
+	byPath := map[string]string{}
+	for _, relativePath := range relativePaths {
+		if hash, ok := hashes[relativePath]; ok {
+			byPath[relativePath] = hash
+		} else if base := path.Base(relativePath); basenames[base] == 1 && hashes[base] != "" {
+			byPath[relativePath] = hashes[base]
+		}
+	}
+	return byPath
+}
+
+func parseFingerprints(json map[string]interface{}) map[string]string {
+	hashes := map[string]string{}
+	fingerprints, _ := json["fingerprint"].([]interface{})
+	for _, fingerprint := range fingerprints {
+		fingerprintSafe, _ := fingerprint.(map[string]interface{})
+		fileName, _ := fingerprintSafe["fileName"].(string)
+		hash, _ := fingerprintSafe["hash"].(string)
+		hashes[fileName] = hash
+	}
+	return hashes
+}
+
+func (self *Client) headSize(ctx context.Context, theurl string) (int64, error) {
+	ctx, cancel := self.requestContext(ctx)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, "HEAD", theurl, nil)
+	if err != nil {
+		return -1, err
+	}
+	resp, err := self.do(req)
+	if err != nil {
+		return -1, err
+	}
+	resp.Body.Close()
+	if resp.StatusCode != 200 {
+		return -1, &HTTPError{resp.StatusCode, theurl, ""}
+	}
+	return resp.ContentLength, nil
+}
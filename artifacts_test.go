@@ -0,0 +1,347 @@
+package jenkins
+
+import (
+	"crypto/md5"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// artifactMux serves build 2 of job j with files as its artifacts, keyed by
+// relative path, which is also used as the display path
+func artifactMux(result string, files map[string]string) *http.ServeMux {
+	mux := buildMux(result, files)
+	mux.HandleFunc("/job/j/2/artifact/", serveArtifacts(files))
+	return mux
+}
+
+// buildMux serves just the build document of artifactMux
+func buildMux(result string, files map[string]string) *http.ServeMux {
+	build := finishedBuild(2, result)
+	artifacts := []interface{}{}
+	for relativePath := range files {
+		artifacts = append(artifacts, map[string]interface{}{
+			"displayPath":  relativePath,
+			"relativePath": relativePath,
+			"fileName":     path.Base(relativePath),
+		})
+	}
+	build["artifacts"] = artifacts
+	mux := http.NewServeMux()
+	mux.HandleFunc("/job/j/2/api/json", jsonHandler(build))
+	return mux
+}
+
+func serveArtifacts(files map[string]string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		content, ok := files[strings.TrimPrefix(r.URL.Path, "/job/j/2/artifact/")]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		io.WriteString(w, content)
+	}
+}
+
+func checkDownloaded(t *testing.T, output string, files map[string]string) {
+	t.Helper()
+	for relativePath, want := range files {
+		got, err := os.ReadFile(filepath.Join(output, relativePath))
+		if err != nil {
+			t.Error(err)
+		} else if string(got) != want {
+			t.Errorf("%s: got %q, want %q", relativePath, got, want)
+		}
+	}
+}
+
+// openFiles counts the process's open descriptors, or returns -1 where it
+// cannot
+func openFiles() int {
+	entries, err := os.ReadDir("/proc/self/fd")
+	if err != nil {
+		return -1
+	}
+	return len(entries)
+}
+
+func TestGetArtifactsClosesEachFile(t *testing.T) {
+	files := map[string]string{}
+	for i := 0; i < 200; i++ {
+		files["file"+strconv.Itoa(i)] = strconv.Itoa(i)
+	}
+	mux := buildMux("SUCCESS", files)
+	before := openFiles()
+	if before < 0 {
+		t.Skip("cannot count open files")
+	}
+	var lock sync.Mutex
+	peak := 0
+	serve := serveArtifacts(files)
+	mux.HandleFunc("/job/j/2/artifact/", func(w http.ResponseWriter, r *http.Request) {
+		lock.Lock()
+		if open := openFiles(); open > peak {
+			peak = open
+		}
+		lock.Unlock()
+		serve(w, r)
+	})
+	client := newTestClient(t, mux)
+	output := t.TempDir()
+
+	downloaded, err := client.GetArtifacts("j", 2, output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(downloaded) != len(files) {
+		t.Errorf("downloaded %d artifacts, want %d", len(downloaded), len(files))
+	}
+	checkDownloaded(t, output, files)
+	// each worker holds at most a file and the connections at either end
+	if limit := before + 3*DEFAULT_ARTIFACT_CONCURRENCY + 10; peak > limit {
+		t.Errorf("%d files open while downloading, want at most %d", peak, limit)
+	}
+}
+
+func TestGetArtifactsOfUnstableBuild(t *testing.T) {
+	files := map[string]string{"app.tar": "binary"}
+	client := newTestClient(t, artifactMux("UNSTABLE", files))
+
+	if _, err := client.GetArtifacts("j", 2, t.TempDir()); err == nil {
+		t.Error("downloaded an unstable build's artifacts by default")
+	}
+	client.ArtifactResults = []BuildResult{ResultSuccess, ResultUnstable}
+	output := t.TempDir()
+	if _, err := client.GetArtifacts("j", 2, output); err != nil {
+		t.Fatal(err)
+	}
+	checkDownloaded(t, output, files)
+}
+
+func TestGetArtifactsDirMode(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("no Unix permissions")
+	}
+	// the umask applies on top of the mode asked for
+	probe := filepath.Join(t.TempDir(), "probe")
+	if err := os.Mkdir(probe, 0777); err != nil {
+		t.Fatal(err)
+	}
+	stat, err := os.Stat(probe)
+	if err != nil {
+		t.Fatal(err)
+	}
+	umask := 0777 &^ stat.Mode().Perm()
+
+	files := map[string]string{"dist/linux/app": "binary"}
+	tests := []struct {
+		mode os.FileMode
+		want os.FileMode
+	}{
+		{0, 0755},
+		{0700, 0700},
+	}
+	for _, test := range tests {
+		client := newTestClient(t, artifactMux("SUCCESS", files))
+		client.ArtifactDirMode = test.mode
+		output := t.TempDir()
+		if _, err := client.GetArtifacts("j", 2, output); err != nil {
+			t.Fatal(err)
+		}
+		for _, dir := range []string{"dist", "dist/linux"} {
+			stat, err := os.Stat(filepath.Join(output, dir))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got, want := stat.Mode(), os.ModeDir|test.want&^umask; got != want {
+				t.Errorf("ArtifactDirMode %v: %s has mode %v, want %v", test.mode, dir, got, want)
+			}
+		}
+	}
+}
+
+func TestVerifyFingerprintsWithSharedBasename(t *testing.T) {
+	files := map[string]string{"linux/app": "elf", "mac/app": "mach-o", "notes.txt": "notes"}
+	// Jenkins names fingerprints after the file alone
+	fingerprints := []interface{}{
+		map[string]interface{}{"fileName": "app", "hash": md5Hex("mach-o")},
+		map[string]interface{}{"fileName": "notes.txt", "hash": md5Hex("notes")},
+	}
+	build := finishedBuild(2, "SUCCESS")
+	build["fingerprint"] = fingerprints
+	artifacts := []interface{}{}
+	for relativePath := range files {
+		artifacts = append(artifacts, map[string]interface{}{
+			"displayPath":  relativePath,
+			"relativePath": relativePath,
+			"fileName":     path.Base(relativePath),
+		})
+	}
+	build["artifacts"] = artifacts
+	mux := http.NewServeMux()
+	mux.HandleFunc("/job/j/2/api/json", jsonHandler(build))
+	mux.HandleFunc("/job/j/2/artifact/", serveArtifacts(files))
+	client := newTestClient(t, mux)
+	client.VerifyFingerprints = true
+
+	output := t.TempDir()
+	if _, err := client.GetArtifacts("j", 2, output); err != nil {
+		t.Fatal(err)
+	}
+	checkDownloaded(t, output, files)
+
+	files["notes.txt"] = "corrupted"
+	if _, err := client.GetArtifacts("j", 2, t.TempDir()); err == nil || !strings.Contains(err.Error(), "fingerprint mismatch") {
+		t.Errorf("got %v, want a fingerprint mismatch for notes.txt", err)
+	}
+
+	manifest, err := client.GetArtifactManifest("j", 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, meta := range manifest {
+		want := ""
+		if meta.RelativePath == "notes.txt" {
+			want = md5Hex("notes")
+		}
+		if meta.MD5 != want {
+			t.Errorf("%s: got MD5 %q, want %q", meta.RelativePath, meta.MD5, want)
+		}
+	}
+}
+
+func md5Hex(content string) string {
+	sum := md5.Sum([]byte(content))
+	return hex.EncodeToString(sum[:])
+}
+
+func TestGetArtifactsRefusesEscapingPaths(t *testing.T) {
+	for _, displayPath := range []string{"../x", "a/../../x", "/etc/passwd", `a\..\..\x`} {
+		build := finishedBuild(2, "SUCCESS")
+		build["artifacts"] = []interface{}{
+			map[string]interface{}{"displayPath": displayPath, "relativePath": "x", "fileName": "x"},
+		}
+		mux := http.NewServeMux()
+		mux.HandleFunc("/job/j/2/api/json", jsonHandler(build))
+		mux.HandleFunc("/job/j/2/artifact/", serveArtifacts(map[string]string{"x": "payload"}))
+		client := newTestClient(t, mux)
+		root := t.TempDir()
+		output := filepath.Join(root, "a", "out")
+		if err := os.MkdirAll(output, 0755); err != nil {
+			t.Fatal(err)
+		}
+
+		_, err := client.GetArtifacts("j", 2, output)
+		if err == nil || !strings.Contains(err.Error(), displayPath) {
+			t.Errorf("%s: got %v, want an error naming the path", displayPath, err)
+		}
+		filepath.Walk(root, func(file string, info os.FileInfo, err error) error {
+			if err == nil && !info.IsDir() {
+				t.Errorf("%s: wrote %s", displayPath, file)
+			}
+			return nil
+		})
+	}
+}
+
+// rangeArtifact serves content at /job/j/2/artifact/app.txt, honouring Range
+// headers only if asked to, and records the ranges requested
+func rangeArtifact(content string, honour bool, ranges *[]string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		*ranges = append(*ranges, r.Header.Get("Range"))
+		if honour {
+			http.ServeContent(w, r, "app.txt", time.Time{}, strings.NewReader(content))
+			return
+		}
+		io.WriteString(w, content)
+	}
+}
+
+func TestGetArtifactsResume(t *testing.T) {
+	const content = "hello world"
+	tests := []struct {
+		name    string
+		partial string
+		honour  bool
+		ranges  []string
+		tee     string
+	}{
+		{"partial content", "hello ", true, []string{"bytes=6-"}, "world"},
+		{"range ignored", "hello ", false, []string{"bytes=6-"}, content},
+		{"already complete", content, true, []string{"bytes=11-", ""}, content},
+		{"nothing yet", "", true, []string{""}, content},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ranges := []string{}
+			mux := buildMux("SUCCESS", map[string]string{"app.txt": content})
+			mux.HandleFunc("/job/j/2/artifact/app.txt", rangeArtifact(content, test.honour, &ranges))
+			client := newTestClient(t, mux)
+			output := t.TempDir()
+			if test.partial != "" {
+				if err := os.WriteFile(filepath.Join(output, "app.txt"), []byte(test.partial), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			var tee strings.Builder
+			opts := &ArtifactOptions{Resume: true, Tee: func(string) io.Writer { return &tee }}
+			if _, err := client.GetArtifactsWithOptions("j", 2, output, opts); err != nil {
+				t.Fatal(err)
+			}
+			checkDownloaded(t, output, map[string]string{"app.txt": content})
+			if strings.Join(ranges, ",") != strings.Join(test.ranges, ",") {
+				t.Errorf("requested ranges %q, want %q", ranges, test.ranges)
+			}
+			if tee.String() != test.tee {
+				t.Errorf("Tee saw %q, want %q", tee.String(), test.tee)
+			}
+		})
+	}
+}
+
+func TestGetArtifactsResumeDropsCorruptPartial(t *testing.T) {
+	const content = "hello world"
+	ranges := []string{}
+	mux := http.NewServeMux()
+	build := finishedBuild(2, "SUCCESS")
+	build["artifacts"] = []interface{}{
+		map[string]interface{}{"displayPath": "app.txt", "relativePath": "app.txt", "fileName": "app.txt"},
+	}
+	build["fingerprint"] = []interface{}{
+		map[string]interface{}{"fileName": "app.txt", "hash": md5Hex(content)},
+	}
+	mux.HandleFunc("/job/j/2/api/json", jsonHandler(build))
+	mux.HandleFunc("/job/j/2/artifact/app.txt", rangeArtifact(content, true, &ranges))
+	client := newTestClient(t, mux)
+	client.VerifyFingerprints = true
+	output := t.TempDir()
+	outfile := filepath.Join(output, "app.txt")
+	if err := os.WriteFile(outfile, []byte("HELLO "), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	opts := &ArtifactOptions{Resume: true}
+	if _, err := client.GetArtifactsWithOptions("j", 2, output, opts); err == nil || !strings.Contains(err.Error(), "fingerprint mismatch") {
+		t.Fatalf("got %v, want a fingerprint mismatch", err)
+	}
+	if _, err := os.Stat(outfile); !os.IsNotExist(err) {
+		t.Errorf("kept the corrupt partial file: %v", err)
+	}
+	if _, err := client.GetArtifactsWithOptions("j", 2, output, opts); err != nil {
+		t.Fatal(err)
+	}
+	checkDownloaded(t, output, map[string]string{"app.txt": content})
+	if strings.Join(ranges, ",") != "bytes=6-," {
+		t.Errorf("requested ranges %q, want a resume then a full download", ranges)
+	}
+}
@@ -0,0 +1,69 @@
+package jenkins
+
+import (
+	"context"
+	"time"
+)
+
+// Backoff produces a capped exponential series of wait intervals: Initial,
+// Initial*Multiplier, ... up to Max. The zero Multiplier means 2.
+type Backoff struct {
+	Initial    time.Duration
+	Max        time.Duration
+	Multiplier float64
+	current    time.Duration
+}
+
+// Next returns the interval to wait now and advances the series.
+func (self *Backoff) Next() time.Duration {
+	if self.current <= 0 {
+		self.current = self.Initial
+	}
+	next := self.current
+	multiplier := self.Multiplier
+	if multiplier <= 0 {
+		multiplier = 2
+	}
+	grown := time.Duration(float64(self.current) * multiplier)
+	if self.Max > 0 && grown > self.Max {
+		grown = self.Max
+	}
+	self.current = grown
+	if self.Max > 0 && next > self.Max {
+		next = self.Max
+	}
+	return next
+}
+
+// Reset starts the series over from Initial.
+func (self *Backoff) Reset() {
+	self.current = 0
+}
+
+// pollBackoff is fixed at PollInterval unless MaxPollInterval allows it to grow
+func (self *Client) pollBackoff() *Backoff {
+	backoff := &Backoff{Initial: self.pollInterval(), Max: self.pollInterval()}
+	if self.MaxPollInterval > backoff.Initial {
+		backoff.Max = self.MaxPollInterval
+	}
+	return backoff
+}
+
+func (self *Client) sleep(ctx context.Context, d time.Duration) error {
+	if self.Sleep != nil {
+		return self.Sleep(ctx, d)
+	}
+	return sleepContext(ctx, d)
+}
+
+// sleeps for d unless ctx is done first
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,81 @@
+package jenkins
+
+import (
+	"context"
+	"log"
+	"path"
+	"strconv"
+)
+
+type BlueStage struct {
+	ID       string
+	Name     string
+	Type     string
+	State    string
+	Result   string
+	Duration float64
+}
+
+type BlueRun struct {
+	ID        string
+	Pipeline  string
+	State     string
+	Result    string
+	Duration  float64
+	StartTime string
+	Stages    []BlueStage
+}
+
+func (self *BlueRun) Print() {
+	log.Println("Blue Ocean Run For", self.Pipeline, "#"+self.ID)
+	log.Println("  state     :", self.State)
+	log.Println("  result    :", self.Result)
+	log.Println("  duration  :", strconv.FormatFloat(self.Duration, 'f', -1, 64))
+	log.Println("  startTime :", self.StartTime)
+	for _, stage := range self.Stages {
+		log.Println("  stage     :", stage.Name, stage.State, stage.Result)
+	}
+}
+
+// Blue Ocean addresses folder/job as pipelines/folder/pipelines/job
+func bluePipelinePath(name string) string {
+	return nestedPath("pipelines", name)
+}
+
+func (self *Client) GetBlueOceanRun(name string, id int) (*BlueRun, error) {
+	id, err := self.sanitizeID(context.Background(), name, id)
+	if err != nil {
+		return nil, err
+	}
+	runPath := path.Join("blue", "rest", "organizations", "jenkins",
+		bluePipelinePath(name), "runs", strconv.Itoa(id))
+	json, err := self.getJSON(context.Background(), self.url(runPath)+"/")
+	if err != nil || json == nil {
+		return nil, err
+	}
+	run := BlueRun{}
+	run.ID, _ = json["id"].(string)
+	run.Pipeline, _ = json["pipeline"].(string)
+	run.State, _ = json["state"].(string)
+	run.Result, _ = json["result"].(string)
+	run.Duration, _ = json["durationInMillis"].(float64)
+	run.StartTime, _ = json["startTime"].(string)
+
+	nodes := []interface{}{}
+	if err := self.decodeJSON(context.Background(), self.url(runPath, "nodes")+"/", &nodes); err != nil {
+		return nil, err
+	}
+	run.Stages = make([]BlueStage, 0, len(nodes))
+	for _, node := range nodes {
+		nodeSafe, _ := node.(map[string]interface{})
+		stage := BlueStage{}
+		stage.ID, _ = nodeSafe["id"].(string)
+		stage.Name, _ = nodeSafe["displayName"].(string)
+		stage.Type, _ = nodeSafe["type"].(string)
+		stage.State, _ = nodeSafe["state"].(string)
+		stage.Result, _ = nodeSafe["result"].(string)
+		stage.Duration, _ = nodeSafe["durationInMillis"].(float64)
+		run.Stages = append(run.Stages, stage)
+	}
+	return &run, nil
+}
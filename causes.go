@@ -0,0 +1,39 @@
+package jenkins
+
+import (
+	"strings"
+)
+
+// BuildCause explains why a build ran, e.g. "Started by user jdoe" or
+// "Started by an SCM change".
+type BuildCause struct {
+	// short class name such as UserIdCause or SCMTriggerCause
+	Class       string `json:"class"`
+	Description string `json:"description"`
+	// empty unless a user started the build
+	UserID   string `json:"userId"`
+	UserName string `json:"userName"`
+}
+
+func parseBuildCauses(actions []actionResponse) []BuildCause {
+	causes := []BuildCause{}
+	for _, action := range actions {
+		for _, value := range action.Causes {
+			cause := BuildCause{}
+			cause.Class = value.Class[strings.LastIndexAny(value.Class, ".$")+1:]
+			cause.Description = value.ShortDescription
+			cause.UserID = value.UserID
+			cause.UserName = value.UserName
+			causes = append(causes, cause)
+		}
+	}
+	return causes
+}
+
+func (self *JenkinsBuildInfo) causeDescriptions() []string {
+	descriptions := []string{}
+	for _, cause := range self.Causes {
+		descriptions = append(descriptions, cause.Description)
+	}
+	return descriptions
+}
@@ -0,0 +1,66 @@
+package jenkins
+
+import (
+	"strconv"
+)
+
+// Change is one SCM commit that went into a build.
+type Change struct {
+	// the Git commit id, or the revision for other SCMs
+	CommitID      string   `json:"commitId"`
+	Author        string   `json:"author"`
+	Message       string   `json:"message"`
+	AffectedPaths []string `json:"affectedPaths"`
+	// milliseconds since the epoch, when the SCM reports it
+	Timestamp float64 `json:"timestamp"`
+}
+
+type changeSetResponse struct {
+	Items []struct {
+		CommitID string `json:"commitId"`
+		ID       string `json:"id"`
+		// Subversion reports a numeric revision rather than a commit id
+		Revision int `json:"revision"`
+		Author   struct {
+			FullName string `json:"fullName"`
+		} `json:"author"`
+		Msg           string   `json:"msg"`
+		AffectedPaths []string `json:"affectedPaths"`
+		Timestamp     float64  `json:"timestamp"`
+	} `json:"items"`
+}
+
+// parseChanges merges the single changeSet of freestyle builds with the
+// changeSets list pipelines report, one per checkout.
+func parseChanges(changeSet *changeSetResponse, changeSets []changeSetResponse) []Change {
+	if changeSet != nil {
+		changeSets = append([]changeSetResponse{*changeSet}, changeSets...)
+	}
+	changes := []Change{}
+	for _, set := range changeSets {
+		for _, item := range set.Items {
+			change := Change{}
+			change.CommitID = item.CommitID
+			if change.CommitID == "" {
+				change.CommitID = item.ID
+			}
+			if change.CommitID == "" && item.Revision > 0 {
+				change.CommitID = strconv.Itoa(item.Revision)
+			}
+			change.Author = item.Author.FullName
+			change.Message = item.Msg
+			change.AffectedPaths = item.AffectedPaths
+			change.Timestamp = item.Timestamp
+			changes = append(changes, change)
+		}
+	}
+	return changes
+}
+
+func (self *JenkinsBuildInfo) changeIDs() []string {
+	ids := []string{}
+	for _, change := range self.Changes {
+		ids = append(ids, change.CommitID)
+	}
+	return ids
+}
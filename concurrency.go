@@ -0,0 +1,118 @@
+package jenkins
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sort"
+	"time"
+)
+
+// how many recent builds per job GetBuildConcurrency considers
+const concurrencyBuildsPerJob = "100"
+
+type BuildInterval struct {
+	Start time.Time
+	End   time.Time
+}
+
+type ConcurrencyPoint struct {
+	Time time.Time
+	// the peak number of builds running at once during the bucket
+	Count int
+}
+
+type concurrencyEvent struct {
+	at    time.Time
+	delta int
+}
+
+// ComputeConcurrency splits [start, end) into buckets and returns the peak
+// number of overlapping intervals within each one. Intervals are half-open, so
+// a build ending exactly when another starts does not count as overlapping.
+func ComputeConcurrency(intervals []BuildInterval, start, end time.Time, bucket time.Duration) []ConcurrencyPoint {
+	events := make([]concurrencyEvent, 0, 2*len(intervals))
+	for _, interval := range intervals {
+		if !interval.End.After(interval.Start) {
+			continue
+		}
+		events = append(events, concurrencyEvent{interval.Start, 1}, concurrencyEvent{interval.End, -1})
+	}
+	sort.Slice(events, func(i, j int) bool {
+		if events[i].at.Equal(events[j].at) {
+			return events[i].delta < events[j].delta
+		}
+		return events[i].at.Before(events[j].at)
+	})
+	points := []ConcurrencyPoint{}
+	if bucket <= 0 {
+		return points
+	}
+	count, next := 0, 0
+	for bucketStart := start; bucketStart.Before(end); bucketStart = bucketStart.Add(bucket) {
+		bucketEnd := bucketStart.Add(bucket)
+		for next < len(events) && !events[next].at.After(bucketStart) {
+			count += events[next].delta
+			next++
+		}
+		peak := count
+		for next < len(events) && events[next].at.Before(bucketEnd) {
+			count += events[next].delta
+			next++
+			if count > peak {
+				peak = count
+			}
+		}
+		points = append(points, ConcurrencyPoint{bucketStart, peak})
+	}
+	return points
+}
+
+// GetBuildConcurrency returns the peak number of concurrent builds across all
+// jobs, including those inside folders, for each bucket of the last window,
+// e.g. per hour over the last day.
+func (self *Client) GetBuildConcurrency(window, bucket time.Duration) ([]ConcurrencyPoint, error) {
+	if bucket <= 0 || window <= 0 {
+		return nil, errors.New("window and bucket must be positive")
+	}
+	end := time.Now()
+	start := end.Add(-window)
+	intervals := []BuildInterval{}
+	fields := "builds[timestamp,duration,building]{0," + concurrencyBuildsPerJob + "}"
+	err := self.walkJobs(context.Background(), "", fields, func(fullName string, data json.RawMessage) error {
+		job := struct {
+			Builds []struct {
+				Timestamp float64 `json:"timestamp"`
+				Duration  float64 `json:"duration"`
+				Building  bool    `json:"building"`
+			} `json:"builds"`
+		}{}
+		if err := skipTypeErrors(json.Unmarshal(data, &job)); err != nil {
+			return err
+		}
+		for _, build := range job.Builds {
+			interval := BuildInterval{Start: millisToTime(build.Timestamp)}
+			if build.Building {
+				interval.End = end
+			} else {
+				interval.End = interval.Start.Add(millisToDuration(build.Duration))
+			}
+			if interval.End.After(start) {
+				intervals = append(intervals, interval)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return ComputeConcurrency(intervals, start, end, bucket), nil
+}
+
+func millisToTime(millis float64) time.Time {
+	return time.Unix(0, int64(millis*float64(time.Millisecond)))
+}
+
+func millisToDuration(millis float64) time.Duration {
+	return time.Duration(millis * float64(time.Millisecond))
+}
@@ -0,0 +1,78 @@
+package jenkins
+
+import (
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestGetBuildConcurrencyInFolders(t *testing.T) {
+	now := time.Now()
+	running := map[string]interface{}{
+		"timestamp": float64(now.Add(-30*time.Minute).UnixNano() / int64(time.Millisecond)),
+		"building":  true,
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/json", jsonHandler(map[string]interface{}{
+		"jobs": []interface{}{
+			map[string]interface{}{"fullName": "top", "builds": []interface{}{running}},
+			map[string]interface{}{"fullName": "team", "jobs": []interface{}{}},
+		},
+	}))
+	mux.HandleFunc("/job/team/api/json", jsonHandler(map[string]interface{}{
+		"jobs": []interface{}{map[string]interface{}{"fullName": "team/svc", "builds": []interface{}{running}}},
+	}))
+	client := newTestClient(t, mux)
+
+	points, err := client.GetBuildConcurrency(10*time.Minute, 10*time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(points) != 1 || points[0].Count != 2 {
+		t.Errorf("got %+v, want one bucket with both running builds", points)
+	}
+}
+
+func TestComputeConcurrency(t *testing.T) {
+	t0 := time.Unix(1000000, 0)
+	at := func(minutes int) time.Time {
+		return t0.Add(time.Duration(minutes) * time.Minute)
+	}
+	build := func(from, to int) BuildInterval {
+		return BuildInterval{at(from), at(to)}
+	}
+	tests := []struct {
+		name      string
+		intervals []BuildInterval
+		// the range, in minutes, split into 10 minute buckets
+		from, to int
+		want     []int
+	}{
+		{"none", nil, 0, 20, []int{0, 0}},
+		{"overlap", []BuildInterval{build(0, 10), build(5, 15)}, 0, 20, []int{2, 1}},
+		{"back to back", []BuildInterval{build(0, 5), build(5, 10)}, 0, 10, []int{1}},
+		{"ends at bucket start", []BuildInterval{build(0, 10)}, 0, 20, []int{1, 0}},
+		{"starts before range", []BuildInterval{build(-30, 5), build(-20, 15)}, 0, 20, []int{2, 1}},
+		{"ends before range", []BuildInterval{build(-30, -5)}, 0, 10, []int{0}},
+		{"zero length", []BuildInterval{build(3, 3), build(4, 2)}, 0, 10, []int{0}},
+		{"spans buckets", []BuildInterval{build(0, 30), build(12, 14), build(13, 18)}, 0, 40, []int{1, 3, 1, 0}},
+		{"partial last bucket", []BuildInterval{build(12, 14)}, 0, 15, []int{0, 1}},
+	}
+	for _, test := range tests {
+		points := ComputeConcurrency(test.intervals, at(test.from), at(test.to), 10*time.Minute)
+		counts := []int{}
+		for i, point := range points {
+			if want := at(test.from + 10*i); !point.Time.Equal(want) {
+				t.Errorf("%s: bucket %d starts at %v, want %v", test.name, i, point.Time, want)
+			}
+			counts = append(counts, point.Count)
+		}
+		if !reflect.DeepEqual(counts, test.want) {
+			t.Errorf("%s: got peaks %v, want %v", test.name, counts, test.want)
+		}
+	}
+	if points := ComputeConcurrency([]BuildInterval{build(0, 10)}, at(0), at(10), 0); len(points) != 0 {
+		t.Errorf("got %v for a zero bucket, want none", points)
+	}
+}
@@ -0,0 +1,115 @@
+package jenkins
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"io"
+	"strings"
+)
+
+type SCMRemote struct {
+	Name          string
+	Url           string
+	Refspec       string
+	CredentialsID string
+}
+
+type SCMConfig struct {
+	Class    string
+	Remotes  []SCMRemote
+	Branches []string
+}
+
+const (
+	TIMER_TRIGGER string = "hudson.triggers.TimerTrigger"
+	SCM_TRIGGER   string = "hudson.triggers.SCMTrigger"
+)
+
+type Trigger struct {
+	// TIMER_TRIGGER or SCM_TRIGGER
+	Class string
+	// cron-style schedule, e.g. "H 2 * * *"
+	Spec string
+}
+
+type xmlSCM struct {
+	Class   string `xml:"class,attr"`
+	Remotes []struct {
+		Name          string `xml:"name"`
+		Url           string `xml:"url"`
+		Refspec       string `xml:"refspec"`
+		CredentialsID string `xml:"credentialsId"`
+	} `xml:"userRemoteConfigs>hudson.plugins.git.UserRemoteConfig"`
+	Branches []string `xml:"branches>hudson.plugins.git.BranchSpec>name"`
+}
+
+type xmlTrigger struct {
+	XMLName xml.Name
+	Spec    string `xml:"spec"`
+}
+
+type xmlTriggers struct {
+	Triggers []xmlTrigger `xml:",any"`
+}
+
+type xmlJobConfig struct {
+	SCM *xmlSCM `xml:"scm"`
+	// pipeline jobs loading their Jenkinsfile from SCM
+	DefinitionSCM *xmlSCM     `xml:"definition>scm"`
+	Triggers      xmlTriggers `xml:"triggers"`
+	// pipeline jobs keep their triggers in a job property
+	PipelineTriggers xmlTriggers `xml:"properties>org.jenkinsci.plugins.workflow.job.properties.PipelineTriggersJobProperty>triggers"`
+}
+
+func (self *Client) getConfig(name string, v interface{}) error {
+	resp, err := self.getRemote(context.Background(), self.url(jobPath(name), "config.xml"))
+	if err != nil {
+		return err
+	}
+	defer resp.Close()
+	data, err := io.ReadAll(resp)
+	if err != nil {
+		return err
+	}
+	// newer Jenkins writes XML 1.1 headers, which encoding/xml refuses to parse
+	data = bytes.Replace(data, []byte("<?xml version='1.1'"), []byte("<?xml version='1.0'"), 1)
+	data = bytes.Replace(data, []byte(`<?xml version="1.1"`), []byte(`<?xml version="1.0"`), 1)
+	return xml.Unmarshal(data, v)
+}
+
+func (self *Client) GetSCMConfig(name string) (*SCMConfig, error) {
+	config := xmlJobConfig{}
+	if err := self.getConfig(name, &config); err != nil {
+		return nil, err
+	}
+	scm := config.SCM
+	if scm == nil || scm.Class == "" {
+		scm = config.DefinitionSCM
+	}
+	info := SCMConfig{Remotes: []SCMRemote{}, Branches: []string{}}
+	if scm == nil {
+		return &info, nil
+	}
+	info.Class = scm.Class
+	for _, remote := range scm.Remotes {
+		info.Remotes = append(info.Remotes, SCMRemote{remote.Name, remote.Url, remote.Refspec, remote.CredentialsID})
+	}
+	info.Branches = append(info.Branches, scm.Branches...)
+	return &info, nil
+}
+
+func (self *Client) GetTriggers(name string) ([]Trigger, error) {
+	config := xmlJobConfig{}
+	if err := self.getConfig(name, &config); err != nil {
+		return nil, err
+	}
+	triggers := []Trigger{}
+	for _, trigger := range append(config.Triggers.Triggers, config.PipelineTriggers.Triggers...) {
+		class := trigger.XMLName.Local
+		if class == TIMER_TRIGGER || class == SCM_TRIGGER {
+			triggers = append(triggers, Trigger{class, strings.TrimSpace(trigger.Spec)})
+		}
+	}
+	return triggers, nil
+}
@@ -0,0 +1,174 @@
+package jenkins
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"path"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const progressiveLogInterval = 1000 * time.Millisecond
+
+// GetConsoleLog returns the full console output of a build as plain text.
+func (self *Client) GetConsoleLog(name string, id int) (io.ReadCloser, error) {
+	id, err := self.sanitizeID(context.Background(), name, id)
+	if err != nil {
+		return nil, err
+	}
+	nameAndID := path.Join(jobPath(name), strconv.Itoa(id))
+	return self.getRemote(context.Background(), self.url(nameAndID, "consoleText"))
+}
+
+// FollowConsoleLog copies a build's console log to w through the progressive
+// log endpoint, polling until Jenkins reports no more data.
+func (self *Client) FollowConsoleLog(name string, id int, w io.Writer) error {
+	return self.followConsoleLog(context.Background(), name, id, w)
+}
+
+// TailConsoleLog streams a build's console log as it is written, ending once
+// the build finishes. Closing the reader stops following the log.
+func (self *Client) TailConsoleLog(name string, id int) (io.ReadCloser, error) {
+	return self.tailConsoleLog(context.Background(), name, id)
+}
+
+func (self *Client) tailConsoleLog(ctx context.Context, name string, id int) (io.ReadCloser, error) {
+	id, err := self.sanitizeID(ctx, name, id)
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	reader, writer := io.Pipe()
+	go func() {
+		writer.CloseWithError(self.followConsoleLog(ctx, name, id, writer))
+	}()
+	return &tailReader{reader, cancel}, nil
+}
+
+type tailReader struct {
+	*io.PipeReader
+	cancel context.CancelFunc
+}
+
+func (self *tailReader) Close() error {
+	self.cancel()
+	return self.PipeReader.Close()
+}
+
+func (self *Client) followConsoleLog(ctx context.Context, name string, id int, w io.Writer) error {
+	id, err := self.sanitizeID(ctx, name, id)
+	if err != nil {
+		return err
+	}
+	nameAndID := path.Join(jobPath(name), strconv.Itoa(id))
+	start := int64(0)
+	for {
+		theurl := self.url(nameAndID, "logText", "progressiveText") +
+			"?start=" + strconv.FormatInt(start, 10)
+		resp, err := self.getResponse(ctx, theurl)
+		if err != nil {
+			return err
+		}
+		_, errCopy := io.Copy(w, resp.Body)
+		resp.Body.Close()
+		if errCopy != nil {
+			return errCopy
+		}
+		if size, err := strconv.ParseInt(resp.Header.Get("X-Text-Size"), 10, 64); err == nil {
+			start = size
+		}
+		if resp.Header.Get("X-More-Data") != "true" {
+			return nil
+		}
+		if err := sleepContext(ctx, progressiveLogInterval); err != nil {
+			return err
+		}
+	}
+}
+
+// ConsoleRing is an io.Writer that keeps only the most recent bytes written to
+// it, for showing a bounded live tail of a long console log.
+type ConsoleRing struct {
+	mu    sync.Mutex
+	buf   []byte
+	next  int
+	full  bool
+	total int64
+}
+
+func NewConsoleRing(size int) *ConsoleRing {
+	return &ConsoleRing{buf: make([]byte, size)}
+}
+
+func (self *ConsoleRing) Write(p []byte) (int, error) {
+	self.mu.Lock()
+	defer self.mu.Unlock()
+	n := len(p)
+	self.total += int64(n)
+	size := len(self.buf)
+	if size == 0 {
+		return n, nil
+	}
+	if n >= size {
+		copy(self.buf, p[n-size:])
+		self.next = 0
+		self.full = true
+		return n, nil
+	}
+	copied := copy(self.buf[self.next:], p)
+	if copied < n {
+		copy(self.buf, p[copied:])
+		self.full = true
+	}
+	self.next = (self.next + n) % size
+	if self.next == 0 {
+		self.full = true
+	}
+	return n, nil
+}
+
+// Bytes returns a copy of the retained tail, oldest byte first.
+func (self *ConsoleRing) Bytes() []byte {
+	self.mu.Lock()
+	defer self.mu.Unlock()
+	if !self.full {
+		return append([]byte(nil), self.buf[:self.next]...)
+	}
+	out := make([]byte, 0, len(self.buf))
+	out = append(out, self.buf[self.next:]...)
+	return append(out, self.buf[:self.next]...)
+}
+
+// Lines returns the retained tail split into lines. Once content has been
+// discarded the first, partial line is dropped.
+func (self *ConsoleRing) Lines() []string {
+	data := self.Bytes()
+	self.mu.Lock()
+	truncated := self.total > int64(len(data))
+	self.mu.Unlock()
+	if truncated {
+		if i := bytes.IndexByte(data, '\n'); i >= 0 {
+			data = data[i+1:]
+		} else {
+			data = nil
+		}
+	}
+	data = bytes.TrimSuffix(data, []byte("\n"))
+	if len(data) == 0 {
+		return []string{}
+	}
+	lines := []string{}
+	for _, line := range bytes.Split(data, []byte("\n")) {
+		lines = append(lines, string(line))
+	}
+	return lines
+}
+
+// Total returns the number of bytes written, including discarded ones.
+func (self *ConsoleRing) Total() int64 {
+	self.mu.Lock()
+	defer self.mu.Unlock()
+	return self.total
+}
@@ -0,0 +1,46 @@
+package jenkins
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestConsoleRing(t *testing.T) {
+	tests := []struct {
+		name   string
+		size   int
+		writes []string
+		bytes  string
+		lines  []string
+	}{
+		{"empty", 8, nil, "", []string{}},
+		{"short", 8, []string{"a\nb\n"}, "a\nb\n", []string{"a", "b"}},
+		{"exactly full", 4, []string{"ab", "c\n"}, "abc\n", []string{"abc"}},
+		{"split across the end", 8, []string{"one\ntw", "o\nthree\n"}, "o\nthree\n", []string{"three"}},
+		{"write of size", 4, []string{"xy", "abcd"}, "abcd", []string{}},
+		{"write larger than size", 6, []string{"first\nsecond\nsix\n"}, "d\nsix\n", []string{"six"}},
+		{"truncated without newline", 4, []string{"abcdefgh"}, "efgh", []string{}},
+		{"size 0", 0, []string{"a\n", "b\n"}, "", []string{}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ring := NewConsoleRing(test.size)
+			total := 0
+			for _, w := range test.writes {
+				if n, err := ring.Write([]byte(w)); n != len(w) || err != nil {
+					t.Fatalf("Write(%q) = %d, %v", w, n, err)
+				}
+				total += len(w)
+			}
+			if got := string(ring.Bytes()); got != test.bytes {
+				t.Errorf("Bytes() = %q, want %q", got, test.bytes)
+			}
+			if got := ring.Lines(); strings.Join(got, "|") != strings.Join(test.lines, "|") || len(got) != len(test.lines) {
+				t.Errorf("Lines() = %q, want %q", got, test.lines)
+			}
+			if ring.Total() != int64(total) {
+				t.Errorf("Total() = %d, want %d", ring.Total(), total)
+			}
+		})
+	}
+}
@@ -0,0 +1,280 @@
+package jenkins
+
+import (
+	"context"
+	"io"
+	"time"
+)
+
+// The package-level functions use a default client that talks to
+// JENKINS_SERVER.
+
+// SetServer validates and normalizes server and makes it JENKINS_SERVER.
+func SetServer(server string) error {
+	normalized, err := NormalizeServer(server)
+	if err != nil {
+		return err
+	}
+	JENKINS_SERVER = normalized
+	return nil
+}
+
+func SetCredentials(user, token string) {
+	defaultClient.SetCredentials(user, token)
+}
+
+func SetHeader(key, value string) {
+	defaultClient.SetHeader(key, value)
+}
+
+// SetLogger replaces the standard logger the package-level functions report
+// progress to; nil silences them.
+func SetLogger(logger Logger) {
+	defaultClient.Logger = logger
+}
+
+// SetRateLimit caps the package-level functions at perSecond requests; zero
+// removes the limit.
+func SetRateLimit(perSecond float64) {
+	defaultClient.RequestsPerSecond = perSecond
+}
+
+func GetInfo(name string) (*JenkinsInfo, error) {
+	return defaultClient.GetInfo(name)
+}
+
+func GetInfoContext(ctx context.Context, name string) (*JenkinsInfo, error) {
+	return defaultClient.GetInfoContext(ctx, name)
+}
+
+func GetInfoBatch(names []string) map[string]InfoResult {
+	return defaultClient.GetInfoBatch(names)
+}
+
+func GetBuildInfo(name string, id int) (*JenkinsBuildInfo, error) {
+	return defaultClient.GetBuildInfo(name, id)
+}
+
+func GetLastBuildInfo(name string) (*JenkinsBuildInfo, error) {
+	return defaultClient.GetLastBuildInfo(name)
+}
+
+func GetLastStableBuildInfo(name string) (*JenkinsBuildInfo, error) {
+	return defaultClient.GetLastStableBuildInfo(name)
+}
+
+func GetLastSuccessfulBuildInfo(name string) (*JenkinsBuildInfo, error) {
+	return defaultClient.GetLastSuccessfulBuildInfo(name)
+}
+
+func GetBuildInfoContext(ctx context.Context, name string, id int) (*JenkinsBuildInfo, error) {
+	return defaultClient.GetBuildInfoContext(ctx, name, id)
+}
+
+func DescribeBuild(name string, id int) (*JenkinsBuildInfo, error) {
+	return defaultClient.DescribeBuild(name, id)
+}
+
+func DoBuild(name, params string, wait bool) (*JenkinsBuildInfo, error) {
+	return defaultClient.DoBuild(name, params, wait)
+}
+
+func DoBuildContext(ctx context.Context, name, params string, wait bool) (*JenkinsBuildInfo, error) {
+	return defaultClient.DoBuildContext(ctx, name, params, wait)
+}
+
+func DoBuildWithParams(name string, params map[string]string, wait bool) (*JenkinsBuildInfo, error) {
+	return defaultClient.DoBuildWithParams(name, params, wait)
+}
+
+func WaitForBuild(name string, id int) (*JenkinsBuildInfo, error) {
+	return defaultClient.WaitForBuild(name, id)
+}
+
+func WaitForBuildContext(ctx context.Context, name string, id int) (*JenkinsBuildInfo, error) {
+	return defaultClient.WaitForBuildContext(ctx, name, id)
+}
+
+func DoBuildWithOptions(name, params string, wait bool, opts *BuildOptions) (*JenkinsBuildInfo, error) {
+	return defaultClient.DoBuildWithOptions(name, params, wait, opts)
+}
+
+func GetArtifacts(name string, id int, output string, patterns ...string) ([]string, error) {
+	return defaultClient.GetArtifacts(name, id, output, patterns...)
+}
+
+func GetArtifactsContext(ctx context.Context, name string, id int, output string) ([]string, error) {
+	return defaultClient.GetArtifactsContext(ctx, name, id, output)
+}
+
+func GetArtifactsWithOptions(name string, id int, output string, opts *ArtifactOptions) ([]string, error) {
+	return defaultClient.GetArtifactsWithOptions(name, id, output, opts)
+}
+
+func GetArtifactReader(name string, id int, artifact string) (io.ReadCloser, error) {
+	return defaultClient.GetArtifactReader(name, id, artifact)
+}
+
+func GetArtifactReaderContext(ctx context.Context, name string, id int, artifact string) (io.ReadCloser, error) {
+	return defaultClient.GetArtifactReaderContext(ctx, name, id, artifact)
+}
+
+func ListArtifacts(name string, id int) (map[string]string, error) {
+	return defaultClient.ListArtifacts(name, id)
+}
+
+func CopyArtifact(name string, id int, artifact string, w io.Writer) (int64, error) {
+	return defaultClient.CopyArtifact(name, id, artifact, w)
+}
+
+func DownloadArtifactsZip(name string, id int, w io.Writer) (int64, error) {
+	return defaultClient.DownloadArtifactsZip(name, id, w)
+}
+
+func GetArtifactManifest(name string, id int) ([]ArtifactMeta, error) {
+	return defaultClient.GetArtifactManifest(name, id)
+}
+
+func GetConsoleLog(name string, id int) (io.ReadCloser, error) {
+	return defaultClient.GetConsoleLog(name, id)
+}
+
+func TailConsoleLog(name string, id int) (io.ReadCloser, error) {
+	return defaultClient.TailConsoleLog(name, id)
+}
+
+func FollowConsoleLog(name string, id int, w io.Writer) error {
+	return defaultClient.FollowConsoleLog(name, id, w)
+}
+
+func BuildAndStream(name, params string, w io.Writer) (*JenkinsBuildInfo, error) {
+	return defaultClient.BuildAndStream(name, params, w)
+}
+
+func DoBuildAndStream(name string, params map[string]string, w io.Writer) (*JenkinsBuildInfo, error) {
+	return defaultClient.DoBuildAndStream(name, params, w)
+}
+
+func IsBuildStuck(name string, id int) (bool, error) {
+	return defaultClient.IsBuildStuck(name, id)
+}
+
+func WatchLastBuild(name string, interval time.Duration) (<-chan JenkinsBuildInfo, func()) {
+	return defaultClient.WatchLastBuild(name, interval)
+}
+
+func EstimateDurationFromHistory(name string, samples int) (time.Duration, error) {
+	return defaultClient.EstimateDurationFromHistory(name, samples)
+}
+
+func FindFirstFailure(name string, knownGood, knownBad int) (int, error) {
+	return defaultClient.FindFirstFailure(name, knownGood, knownBad)
+}
+
+func GetBuildConcurrency(window, bucket time.Duration) ([]ConcurrencyPoint, error) {
+	return defaultClient.GetBuildConcurrency(window, bucket)
+}
+
+func JobExists(name string) (bool, error) {
+	return defaultClient.JobExists(name)
+}
+
+func ListJobs() ([]JobSummary, error) {
+	return defaultClient.ListJobs()
+}
+
+func FindStaleJobs(olderThan time.Duration) ([]JenkinsInfo, error) {
+	return defaultClient.FindStaleJobs(olderThan)
+}
+
+func DisableJob(name string) error {
+	return defaultClient.DisableJob(name)
+}
+
+func EnableJob(name string) error {
+	return defaultClient.EnableJob(name)
+}
+
+func DisableJobWithReason(name, reason string) error {
+	return defaultClient.DisableJobWithReason(name, reason)
+}
+
+func EnableJobsDisabledBy(reasonPrefix string) (int, error) {
+	return defaultClient.EnableJobsDisabledBy(reasonPrefix)
+}
+
+func ListBuilds(name string, limit int) ([]BuildSummary, error) {
+	return defaultClient.ListBuilds(name, limit)
+}
+
+func StopBuild(name string, id int) error {
+	return defaultClient.StopBuild(name, id)
+}
+
+func GetQueue() ([]QueueItem, error) {
+	return defaultClient.GetQueue()
+}
+
+func CancelQueueItem(queueID int) error {
+	return defaultClient.CancelQueueItem(queueID)
+}
+
+func SetBuildDescription(name string, id int, description string) error {
+	return defaultClient.SetBuildDescription(name, id, description)
+}
+
+func SetBuildKept(name string, id int, kept bool) error {
+	return defaultClient.SetBuildKept(name, id, kept)
+}
+
+func StopAllBuilds(name string) (int, error) {
+	return defaultClient.StopAllBuilds(name)
+}
+
+func GetJobParameters(name string) ([]ParameterDefinition, error) {
+	return defaultClient.GetJobParameters(name)
+}
+
+func ValidateParams(name string, provided map[string]string) error {
+	return defaultClient.ValidateParams(name, provided)
+}
+
+func MissingRequiredParams(name string, provided map[string]string) ([]string, error) {
+	return defaultClient.MissingRequiredParams(name, provided)
+}
+
+func RedactParams(name, params string) (string, error) {
+	return defaultClient.RedactParams(name, params)
+}
+
+func GetSCMConfig(name string) (*SCMConfig, error) {
+	return defaultClient.GetSCMConfig(name)
+}
+
+func GetTriggers(name string) ([]Trigger, error) {
+	return defaultClient.GetTriggers(name)
+}
+
+func GetNodeInfo(node string) (*NodeInfo, error) {
+	return defaultClient.GetNodeInfo(node)
+}
+
+func GetNodes() (*Nodes, error) {
+	return defaultClient.GetNodes()
+}
+
+func GetBuildNode(name string, id int) (*NodeInfo, error) {
+	return defaultClient.GetBuildNode(name, id)
+}
+
+func GetBlueOceanRun(name string, id int) (*BlueRun, error) {
+	return defaultClient.GetBlueOceanRun(name, id)
+}
+
+func Diagnose() (*Diagnosis, error) {
+	return defaultClient.Diagnose()
+}
+
+func GetTestResults(name string, id int) (*TestResults, error) {
+	return defaultClient.GetTestResults(name, id)
+}
@@ -0,0 +1,81 @@
+package jenkins
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+)
+
+type DiagnosticCheck struct {
+	OK  bool
+	Err error
+}
+
+type Diagnosis struct {
+	Server        string
+	Reachable     DiagnosticCheck
+	Authenticated DiagnosticCheck
+	CrumbIssuer   DiagnosticCheck
+	Version       string
+	User          string
+}
+
+func (self *Diagnosis) Print() {
+	log.Println("Diagnosis For", self.Server)
+	log.Println("  reachable     :", self.Reachable.OK, errString(self.Reachable.Err))
+	log.Println("  authenticated :", self.Authenticated.OK, errString(self.Authenticated.Err))
+	log.Println("  crumbIssuer   :", self.CrumbIssuer.OK, errString(self.CrumbIssuer.Err))
+	log.Println("  version       :", self.Version)
+	log.Println("  user          :", self.User)
+}
+
+func errString(err error) string {
+	if err == nil {
+		return ""
+	}
+	return "(" + err.Error() + ")"
+}
+
+// Diagnose checks whether the server answers, who we are authenticated as and
+// whether a CSRF crumb can be issued. The returned error is only set when the
+// server could not be reached at all; the other checks report their own
+// failures on the Diagnosis.
+func (self *Client) Diagnose() (*Diagnosis, error) {
+	diag := Diagnosis{Server: self.server()}
+	theurl := self.url("api", "json")
+	ctx, cancel := self.requestContext(context.Background())
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, "GET", theurl, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := self.do(req)
+	if err != nil {
+		diag.Reachable.Err = err
+		return &diag, err
+	}
+	resp.Body.Close()
+	diag.Reachable.OK = true
+	diag.Version = resp.Header.Get("X-Jenkins")
+
+	whoAmI, err := self.getJSON(context.Background(), self.url("whoAmI", "api", "json"))
+	if err != nil {
+		diag.Authenticated.Err = err
+	} else {
+		diag.User, _ = whoAmI["name"].(string)
+		authenticated, _ := whoAmI["authenticated"].(bool)
+		anonymous, _ := whoAmI["anonymous"].(bool)
+		diag.Authenticated.OK = authenticated && !anonymous
+		if !diag.Authenticated.OK {
+			diag.Authenticated.Err = errors.New("requests are anonymous")
+		}
+	}
+
+	if _, err := self.getJSON(context.Background(), self.url("crumbIssuer", "api", "json")); err != nil {
+		diag.CrumbIssuer.Err = err
+	} else {
+		diag.CrumbIssuer.OK = true
+	}
+	return &diag, nil
+}
@@ -0,0 +1,28 @@
+package jenkins
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestDiagnoseHungServer(t *testing.T) {
+	hung := make(chan struct{})
+	defer close(hung)
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-hung:
+		case <-r.Context().Done():
+		}
+	}))
+	client.RequestTimeout = 50 * time.Millisecond
+
+	start := time.Now()
+	diag, err := client.Diagnose()
+	if err == nil || diag.Reachable.OK {
+		t.Errorf("got reachable %v, err %v; want unreachable", diag.Reachable.OK, err)
+	}
+	if took := time.Since(start); took > time.Second {
+		t.Errorf("gave up after %v, want about RequestTimeout", took)
+	}
+}
@@ -0,0 +1,41 @@
+package jenkins
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+)
+
+// how much of an error response's body HTTPError keeps
+const httpErrorBodyLimit = 512
+
+// HTTPError is returned when Jenkins answers a request with an unexpected
+// status, so callers can tell e.g. a missing job (404) from bad credentials
+// (401) with errors.As.
+type HTTPError struct {
+	StatusCode int
+	URL        string
+	// the start of the response body, which often says what went wrong
+	Body string
+}
+
+func (self *HTTPError) Error() string {
+	return "Bad status: " + strconv.Itoa(self.StatusCode) + " from " + self.URL
+}
+
+// newHTTPError reads the start of resp's body and closes it
+func newHTTPError(resp *http.Response, theurl string) *HTTPError {
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, httpErrorBodyLimit))
+	resp.Body.Close()
+	return &HTTPError{resp.StatusCode, theurl, string(body)}
+}
+
+func isNotFound(err error) bool {
+	return isStatus(err, http.StatusNotFound)
+}
+
+func isStatus(err error, status int) bool {
+	var httpErr *HTTPError
+	return errors.As(err, &httpErr) && httpErr.StatusCode == status
+}
@@ -0,0 +1,62 @@
+package jenkins
+
+type expectedField struct {
+	name string
+	kind string
+}
+
+var infoFields = []expectedField{
+	{"name", "string"},
+	{"description", "string"},
+	{"url", "string"},
+	{"buildable", "bool"},
+	{"inQueue", "bool"},
+	{"lastBuild", "object"},
+	{"lastStableBuild", "object"},
+	{"lastSuccessfulBuild", "object"},
+	{"lastFailedBuild", "object"},
+}
+
+var buildFields = []expectedField{
+	{"fullDisplayName", "string"},
+	{"number", "number"},
+	{"artifacts", "array"},
+	{"building", "bool"},
+	{"duration", "number"},
+	{"estimatedDuration", "number"},
+	{"result", "string"},
+	{"timestamp", "number"},
+	{"url", "string"},
+}
+
+// checkFields warns about expected fields that are missing or have an
+// unexpected type; the parsers would otherwise silently leave them zero. null
+// is accepted for any field since Jenkins uses it for "not yet" values.
+func (self *Client) checkFields(what string, json map[string]interface{}, fields []expectedField) {
+	for _, field := range fields {
+		value, ok := json[field.name]
+		if !ok {
+			self.logger().Print("Warning: ", what, " is missing field \"", field.name, "\"")
+		} else if kind := jsonKind(value); value != nil && kind != field.kind {
+			self.logger().Print("Warning: ", what, " field \"", field.name, "\" is ", kind, ", expected ", field.kind)
+		}
+	}
+}
+
+func jsonKind(value interface{}) string {
+	switch value.(type) {
+	case nil:
+		return "null"
+	case string:
+		return "string"
+	case bool:
+		return "bool"
+	case float64:
+		return "number"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	}
+	return "unknown"
+}
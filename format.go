@@ -0,0 +1,139 @@
+package jenkins
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// formats one line the way log.Println would, minus the trailing newline
+func textLine(a ...interface{}) string {
+	line := fmt.Sprintln(a...)
+	return line[:len(line)-1]
+}
+
+// Format writes the job info as "text" (the same lines Print logs), "json" or
+// "yaml".
+func (self *JenkinsInfo) Format(w io.Writer, format string) error {
+	return formatValue(w, format, self, self.textLines())
+}
+
+// Format writes the build info as "text" (the same lines Print logs), "json"
+// or "yaml".
+func (self *JenkinsBuildInfo) Format(w io.Writer, format string) error {
+	return formatValue(w, format, self, self.textLines())
+}
+
+func formatValue(w io.Writer, format string, v interface{}, lines []string) error {
+	switch format {
+	case "text", "":
+		_, err := io.WriteString(w, strings.Join(lines, "\n")+"\n")
+		return err
+	case "json":
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(v)
+	case "yaml":
+		_, err := io.WriteString(w, strings.Join(yamlLines(reflect.ValueOf(v), ""), "\n")+"\n")
+		return err
+	}
+	return errors.New("unknown output format: " + format)
+}
+
+// yamlLines renders the block-style YAML for v, which is a struct, map or
+// slice, indenting nested collections under their key.
+func yamlLines(v reflect.Value, indent string) []string {
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return []string{indent + "null"}
+		}
+		v = v.Elem()
+	}
+	lines := []string{}
+	switch v.Kind() {
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			field := v.Type().Field(i)
+			key, ok := yamlKey(field)
+			if !ok {
+				continue
+			}
+			lines = append(lines, yamlEntry(indent+key+":", v.Field(i), indent)...)
+		}
+	case reflect.Map:
+		keys := v.MapKeys()
+		sort.Slice(keys, func(i, j int) bool { return fmt.Sprint(keys[i]) < fmt.Sprint(keys[j]) })
+		for _, key := range keys {
+			lines = append(lines, yamlEntry(indent+yamlScalar(key)+":", v.MapIndex(key), indent)...)
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			lines = append(lines, yamlEntry(indent+"-", v.Index(i), indent)...)
+		}
+	default:
+		lines = append(lines, indent+yamlScalar(v))
+	}
+	return lines
+}
+
+// renders "prefix value" for scalars and empty collections, otherwise the
+// prefix followed by the collection indented one level deeper
+func yamlEntry(prefix string, v reflect.Value, indent string) []string {
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return []string{prefix + " null"}
+		}
+		v = v.Elem()
+	}
+	switch v.Kind() {
+	case reflect.Map, reflect.Slice, reflect.Array:
+		if v.Len() == 0 {
+			if v.Kind() == reflect.Map {
+				return []string{prefix + " {}"}
+			}
+			return []string{prefix + " []"}
+		}
+	case reflect.Struct:
+		if v.Type().PkgPath() == "time" || v.NumField() == 0 {
+			return []string{prefix + " " + yamlScalar(v)}
+		}
+	default:
+		return []string{prefix + " " + yamlScalar(v)}
+	}
+	return append([]string{prefix}, yamlLines(v, indent+"  ")...)
+}
+
+func yamlKey(field reflect.StructField) (string, bool) {
+	if field.PkgPath != "" {
+		return "", false
+	}
+	tag := strings.Split(field.Tag.Get("json"), ",")[0]
+	if tag == "-" {
+		return "", false
+	}
+	if tag != "" {
+		return tag, true
+	}
+	return field.Name, true
+}
+
+func yamlScalar(v reflect.Value) string {
+	switch v.Kind() {
+	case reflect.String:
+		return strconv.Quote(v.String())
+	case reflect.Bool:
+		return strconv.FormatBool(v.Bool())
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(v.Uint(), 10)
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(v.Float(), 'f', -1, 64)
+	}
+	return strconv.Quote(fmt.Sprint(v.Interface()))
+}
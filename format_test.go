@@ -0,0 +1,181 @@
+package jenkins
+
+import (
+	"bytes"
+	"encoding/json"
+	"log"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func testBuildInfo() *JenkinsBuildInfo {
+	return &JenkinsBuildInfo{
+		Name:       "j #2",
+		ID:         2,
+		Artifacts:  map[string]string{"b/app": "b/app", "a.txt": "out/a.txt"},
+		Result:     ResultSuccess,
+		Parameters: map[string]string{},
+		Causes:     []BuildCause{{Class: "UserIdCause", Description: "Started by user jdoe", UserID: "jdoe"}},
+		Changes:    []Change{{CommitID: "abc", Author: "jdoe", Message: "fix", AffectedPaths: []string{"x.go", "y.go"}}},
+	}
+}
+
+func testInfo() *JenkinsInfo {
+	return &JenkinsInfo{Name: "j", Buildable: true, LastBuild: 2, LastBuildUrl: "http://ci/job/j/2/", Color: "blue"}
+}
+
+// captureLog returns what print logs through the standard logger, without
+// timestamps
+func captureLog(print func()) string {
+	var out bytes.Buffer
+	flags, writer := log.Flags(), log.Writer()
+	log.SetFlags(0)
+	log.SetOutput(&out)
+	defer func() {
+		log.SetFlags(flags)
+		log.SetOutput(writer)
+	}()
+	print()
+	return out.String()
+}
+
+func TestFormatText(t *testing.T) {
+	var out bytes.Buffer
+	build := testBuildInfo()
+	if err := build.Format(&out, "text"); err != nil {
+		t.Fatal(err)
+	}
+	if want := captureLog(build.Print); out.String() != want {
+		t.Errorf("build text is\n%s\nPrint logs\n%s", out.String(), want)
+	}
+	out.Reset()
+	info := testInfo()
+	if err := info.Format(&out, "text"); err != nil {
+		t.Fatal(err)
+	}
+	if want := captureLog(info.Print); out.String() != want {
+		t.Errorf("job text is\n%s\nPrint logs\n%s", out.String(), want)
+	}
+}
+
+func TestFormatJSON(t *testing.T) {
+	var out bytes.Buffer
+	build := testBuildInfo()
+	if err := build.Format(&out, "json"); err != nil {
+		t.Fatal(err)
+	}
+	decodedBuild := &JenkinsBuildInfo{}
+	if err := json.Unmarshal(out.Bytes(), decodedBuild); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(decodedBuild, build) {
+		t.Errorf("build json decodes to %+v, want %+v", decodedBuild, build)
+	}
+	if !strings.Contains(out.String(), `"git": null`) {
+		t.Errorf("build json has no null git:\n%s", out.String())
+	}
+	out.Reset()
+	info := testInfo()
+	if err := info.Format(&out, "json"); err != nil {
+		t.Fatal(err)
+	}
+	decodedInfo := &JenkinsInfo{}
+	if err := json.Unmarshal(out.Bytes(), decodedInfo); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(decodedInfo, info) {
+		t.Errorf("job json decodes to %+v, want %+v", decodedInfo, info)
+	}
+}
+
+const buildYAML = `name: "j #2"
+id: 2
+artifacts:
+  "a.txt": "out/a.txt"
+  "b/app": "b/app"
+building: false
+duration: 0
+estimatedDuration: 0
+result: "SUCCESS"
+timestamp: 0
+url: ""
+builtOn: ""
+parameters: {}
+causes:
+  -
+    class: "UserIdCause"
+    description: "Started by user jdoe"
+    userId: "jdoe"
+    userName: ""
+changes:
+  -
+    commitId: "abc"
+    author: "jdoe"
+    message: "fix"
+    affectedPaths:
+      - "x.go"
+      - "y.go"
+    timestamp: 0
+git: null
+keepLog: false
+description: ""
+previousBuild: 0
+nextBuild: 0
+`
+
+const infoYAML = `name: "j"
+fullName: ""
+description: ""
+url: ""
+buildable: true
+inQueue: false
+lastBuild: 2
+lastBuildUrl: "http://ci/job/j/2/"
+lastStableBuild: 0
+lastStableBuildUrl: ""
+lastSuccessfulBuild: 0
+lastSuccessfulBuildUrl: ""
+lastFailedBuild: 0
+lastFailedBuildUrl: ""
+lastBuildTimestamp: 0
+queueId: 0
+queueStuck: false
+color: "blue"
+building: false
+healthScore: 0
+healthDescription: ""
+`
+
+func TestFormatYAML(t *testing.T) {
+	var out bytes.Buffer
+	build := testBuildInfo()
+	if err := build.Format(&out, "yaml"); err != nil {
+		t.Fatal(err)
+	}
+	if out.String() != buildYAML {
+		t.Errorf("build yaml is\n%s\nwant\n%s", out.String(), buildYAML)
+	}
+	out.Reset()
+	build.Git = &GitInfo{Commit: "abc", Branch: "origin/master"}
+	if err := build.Format(&out, "yaml"); err != nil {
+		t.Fatal(err)
+	}
+	if want := "git:\n  commit: \"abc\"\n  branch: \"origin/master\"\n  remoteUrl: \"\"\n"; !strings.Contains(out.String(), want) {
+		t.Errorf("build yaml is\n%s\nwant it to contain\n%s", out.String(), want)
+	}
+	out.Reset()
+	if err := testInfo().Format(&out, "yaml"); err != nil {
+		t.Fatal(err)
+	}
+	if out.String() != infoYAML {
+		t.Errorf("job yaml is\n%s\nwant\n%s", out.String(), infoYAML)
+	}
+}
+
+func TestFormatUnknown(t *testing.T) {
+	var out bytes.Buffer
+	if err := testInfo().Format(&out, "xml"); err == nil {
+		t.Error("no error for an unknown format")
+	}
+}
@@ -0,0 +1,42 @@
+package jenkins
+
+// GitInfo identifies the commit a Git-backed build checked out.
+type GitInfo struct {
+	Commit string `json:"commit"`
+	// as the Git plugin names it, e.g. "origin/master"
+	Branch    string `json:"branch"`
+	RemoteUrl string `json:"remoteUrl"`
+}
+
+const gitActionClass string = "hudson.plugins.git.util.BuildData"
+
+// parseGitInfo returns the first repository the build checked out, or nil if
+// it has no Git data. Pipelines may also record shared library checkouts,
+// which come after the job's own.
+func parseGitInfo(actions []actionResponse) *GitInfo {
+	for _, action := range actions {
+		if action.Class != gitActionClass || action.LastBuiltRevision == nil {
+			continue
+		}
+		git := GitInfo{}
+		git.Commit = action.LastBuiltRevision.SHA1
+		if len(action.LastBuiltRevision.Branch) > 0 {
+			git.Branch = action.LastBuiltRevision.Branch[0].Name
+		}
+		if len(action.RemoteUrls) > 0 {
+			git.RemoteUrl = action.RemoteUrls[0]
+		}
+		return &git
+	}
+	return nil
+}
+
+func (self *JenkinsBuildInfo) gitCommit() string {
+	if self.Git == nil {
+		return ""
+	}
+	if self.Git.Branch == "" {
+		return self.Git.Commit
+	}
+	return self.Git.Commit + " (" + self.Git.Branch + ")"
+}
@@ -0,0 +1,37 @@
+package jenkins
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// gunzipBody swaps a gzip-encoded response body for its decompressed
+// contents. Setting Accept-Encoding ourselves stops net/http from doing this,
+// but lets it work the same through proxies that compress regardless.
+func gunzipBody(resp *http.Response) error {
+	if !strings.EqualFold(resp.Header.Get("Content-Encoding"), "gzip") {
+		return nil
+	}
+	reader, err := gzip.NewReader(resp.Body)
+	if err != nil {
+		return err
+	}
+	resp.Body = &gzipBody{reader, resp.Body}
+	resp.Header.Del("Content-Encoding")
+	resp.Header.Del("Content-Length")
+	// the decompressed size is unknown
+	resp.ContentLength = -1
+	return nil
+}
+
+type gzipBody struct {
+	*gzip.Reader
+	body io.ReadCloser
+}
+
+func (self *gzipBody) Close() error {
+	self.Reader.Close()
+	return self.body.Close()
+}
@@ -0,0 +1,58 @@
+package jenkins
+
+import (
+	"bytes"
+	"compress/gzip"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+// gzipHandler compresses the responses of next for clients that accept it,
+// counting how many it compressed
+func gzipHandler(next http.Handler, compressed *int32) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !strings.Contains(r.Header.Get("Accept-Encoding"), "gzip") {
+			next.ServeHTTP(w, r)
+			return
+		}
+		recorder := httptest.NewRecorder()
+		next.ServeHTTP(recorder, r)
+		var body bytes.Buffer
+		writer := gzip.NewWriter(&body)
+		writer.Write(recorder.Body.Bytes())
+		writer.Close()
+		for key, values := range recorder.Header() {
+			w.Header()[key] = values
+		}
+		w.Header().Set("Content-Encoding", "gzip")
+		atomic.AddInt32(compressed, 1)
+		w.WriteHeader(recorder.Code)
+		w.Write(body.Bytes())
+	}
+}
+
+func TestGzipResponses(t *testing.T) {
+	files := map[string]string{"report.txt": strings.Repeat("all good\n", 100)}
+	var compressed int32
+	client := newTestClient(t, gzipHandler(artifactMux("SUCCESS", files), &compressed))
+
+	binfo, err := client.GetBuildInfo("j", 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if binfo.ID != 2 || binfo.Artifacts["report.txt"] != "report.txt" {
+		t.Errorf("got %+v, want build #2 with report.txt", binfo)
+	}
+	output := t.TempDir()
+	if _, err := client.GetArtifacts("j", 2, output); err != nil {
+		t.Fatal(err)
+	}
+	checkDownloaded(t, output, files)
+	// the build document twice and the artifact
+	if n := atomic.LoadInt32(&compressed); n != 3 {
+		t.Errorf("%d responses compressed, want 3", n)
+	}
+}
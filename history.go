@@ -0,0 +1,142 @@
+package jenkins
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"sort"
+	"strconv"
+	"time"
+)
+
+type BuildSummary struct {
+	Number    int
+	Result    BuildResult
+	Building  bool
+	Duration  float64
+	Timestamp float64
+}
+
+type buildsResponse struct {
+	Builds []struct {
+		Number   int  `json:"number"`
+		Building bool `json:"building"`
+		// null while the build runs
+		Result    interface{} `json:"result"`
+		Duration  float64     `json:"duration"`
+		Timestamp float64     `json:"timestamp"`
+	} `json:"builds"`
+}
+
+// ListBuilds fetches the most recent builds of a job, newest first, in a
+// single request.
+func (self *Client) ListBuilds(name string, limit int) ([]BuildSummary, error) {
+	tree := "builds[number,result,building,duration,timestamp]{0," + strconv.Itoa(limit) + "}"
+	theurl := self.url(jobPath(name), "api", "json") + "?tree=" + url.QueryEscape(tree)
+	resp := buildsResponse{}
+	if err := skipTypeErrors(self.decodeJSON(context.Background(), theurl, &resp)); err != nil {
+		return nil, err
+	}
+	summaries := make([]BuildSummary, 0, len(resp.Builds))
+	for _, build := range resp.Builds {
+		summaries = append(summaries, BuildSummary{
+			Number:    build.Number,
+			Result:    ParseBuildResult(build.Result, build.Building),
+			Building:  build.Building,
+			Duration:  build.Duration,
+			Timestamp: build.Timestamp,
+		})
+	}
+	return summaries, nil
+}
+
+// EstimateDurationFromHistory returns the median duration of the last samples
+// successful builds, which is less sensitive to a single slow outlier than
+// Jenkins' own estimate.
+func (self *Client) EstimateDurationFromHistory(name string, samples int) (time.Duration, error) {
+	if samples <= 0 {
+		return 0, errors.New("samples must be positive")
+	}
+	limit := samples * 4
+	if limit < 20 {
+		limit = 20
+	}
+	builds, err := self.ListBuilds(name, limit)
+	if err != nil {
+		return 0, err
+	}
+	durations := []float64{}
+	for _, build := range builds {
+		if build.Result == ResultSuccess && !build.Building {
+			durations = append(durations, build.Duration)
+			if len(durations) == samples {
+				break
+			}
+		}
+	}
+	if len(durations) == 0 {
+		return 0, errors.New("no successful builds of " + name + " to estimate from")
+	}
+	sort.Float64s(durations)
+	median := durations[len(durations)/2]
+	if len(durations)%2 == 0 {
+		median = (durations[len(durations)/2-1] + median) / 2
+	}
+	return millisToDuration(median), nil
+}
+
+// FindFirstFailure bisects the builds between knownGood and knownBad and
+// returns the number of the first failing build. Builds that are missing,
+// aborted, not built or still running carry no signal and are stepped over.
+func (self *Client) FindFirstFailure(name string, knownGood, knownBad int) (int, error) {
+	if knownGood <= 0 || knownBad <= knownGood {
+		return 0, errors.New("known good build must precede known bad build")
+	}
+	good, bad := knownGood, knownBad
+	for bad-good > 1 {
+		mid := good + (bad-good)/2
+		probe, failed, err := self.probeNear(name, mid, good, bad)
+		if err != nil {
+			return 0, err
+		}
+		if probe == 0 {
+			// nothing between good and bad has a usable result
+			break
+		}
+		if failed {
+			bad = probe
+		} else {
+			good = probe
+		}
+	}
+	return bad, nil
+}
+
+// looks for the build closest to mid, strictly between good and bad, that has
+// a pass/fail result. Returns 0 if there is none.
+func (self *Client) probeNear(name string, mid, good, bad int) (int, bool, error) {
+	for offset := 0; mid+offset < bad || mid-offset > good; offset++ {
+		candidates := []int{mid + offset, mid - offset}
+		if offset == 0 {
+			candidates = candidates[:1]
+		}
+		for _, candidate := range candidates {
+			if candidate <= good || candidate >= bad {
+				continue
+			}
+			info, err := self.GetBuildInfo(name, candidate)
+			if isNotFound(err) {
+				continue
+			} else if err != nil {
+				return 0, false, err
+			}
+			switch info.Result {
+			case ResultSuccess:
+				return candidate, false, nil
+			case ResultFailure, ResultUnstable:
+				return candidate, true, nil
+			}
+		}
+	}
+	return 0, false, nil
+}
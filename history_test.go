@@ -0,0 +1,97 @@
+package jenkins
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// historyMux serves builds of job j by number; results absent from the map
+// are missing builds
+func historyMux(t *testing.T, results map[int]string, good, bad int) *http.ServeMux {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/job/j/", func(w http.ResponseWriter, r *http.Request) {
+		rest := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/job/j/"), "/api/json")
+		number, err := strconv.Atoi(rest)
+		if err != nil {
+			http.NotFound(w, r)
+			return
+		}
+		if number <= good || number >= bad {
+			t.Errorf("probed build %d outside (%d, %d)", number, good, bad)
+		}
+		result, ok := results[number]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		build := finishedBuild(number, result)
+		if result == "" {
+			build["building"] = true
+			build["result"] = nil
+		}
+		writeJSON(w, build)
+	})
+	return mux
+}
+
+func TestFindFirstFailure(t *testing.T) {
+	tests := []struct {
+		name    string
+		results map[int]string
+		want    int
+	}{
+		{"steps over missing and aborted builds", map[int]string{
+			2: "SUCCESS", 3: "SUCCESS", 5: "ABORTED", 6: "ABORTED", 7: "FAILURE", 9: "UNSTABLE",
+		}, 7},
+		{"first failure next to a gap", map[int]string{
+			2: "SUCCESS", 4: "NOT_BUILT", 5: "ABORTED", 6: "FAILURE", 8: "FAILURE",
+		}, 6},
+		{"no usable result in range", map[int]string{
+			2: "ABORTED", 3: "NOT_BUILT", 5: "", 6: "ABORTED",
+		}, 10},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			client := newTestClient(t, historyMux(t, test.results, 1, 10))
+			got, err := client.FindFirstFailure("j", 1, 10)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != test.want {
+				t.Errorf("got build %d, want %d", got, test.want)
+			}
+		})
+	}
+}
+
+func TestListBuilds(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/job/j/api/json", jsonHandler(map[string]interface{}{
+		"builds": []interface{}{
+			map[string]interface{}{"number": 3, "building": true, "result": nil, "timestamp": 3000},
+			map[string]interface{}{"number": 2, "result": "FAILURE", "duration": 20, "timestamp": 2000},
+			map[string]interface{}{"number": 1, "result": "SUCCESS", "duration": 10, "timestamp": 1000},
+		},
+	}))
+	client := newTestClient(t, mux)
+
+	builds, err := client.ListBuilds("j", 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []BuildSummary{
+		{Number: 3, Result: ResultBuilding, Building: true, Timestamp: 3000},
+		{Number: 2, Result: ResultFailure, Duration: 20, Timestamp: 2000},
+		{Number: 1, Result: ResultSuccess, Duration: 10, Timestamp: 1000},
+	}
+	if len(builds) != len(want) {
+		t.Fatalf("got %d builds, want %d", len(builds), len(want))
+	}
+	for i := range want {
+		if builds[i] != want[i] {
+			t.Errorf("build %d: got %+v, want %+v", i, builds[i], want[i])
+		}
+	}
+}
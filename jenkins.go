@@ -1,15 +1,22 @@
 package jenkins
 
 import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"io"
 	"log"
+	"mime/multipart"
 	"net/http"
 	"net/url"
 	"os"
 	"path"
 	"strconv"
+	"strings"
+	"sync"
 	"time"
 )
 
@@ -17,300 +24,1493 @@ const DEFAULT_SERVER string = "alfred-jenkins.sv2:8080"
 
 var JENKINS_SERVER string = DEFAULT_SERVER
 
-type JenkinsInfo struct {
-	Name               string
-	Description        string
-	Url                string
-	Buildable          bool
-	InQueue            bool
-	LastBuild          int
-	LastBuildUrl       string
-	LastStableBuild    int
-	LastStableBuildUrl string
+type Client struct {
+	// host:port of the server, optionally prefixed with a scheme such as
+	// "https://"; when empty JENKINS_SERVER is used
+	Server string
+	// used when Server has no scheme of its own; defaults to "http"
+	Scheme string
+	// ContextPath is the path Jenkins is served under, such as "/jenkins",
+	// when it is not already part of Server
+	ContextPath string
+	// sends every request; defaults to http.DefaultClient
+	HTTPClient *http.Client
+	// how often DoBuild checks on a queued or running build; defaults to 1s
+	PollInterval time.Duration
+	// MaxPollInterval lets the poll interval double on every check that sees
+	// no change, up to this cap; it resets whenever the build changes state
+	MaxPollInterval time.Duration
+	// DelayFirstPoll makes DoBuild wait for the last stable build's duration
+	// before its first status check, for long builds
+	DelayFirstPoll bool
+	// WaitTimeout bounds how long DoBuild waits for the build to finish. Zero
+	// means WAIT_TIMEOUT_FACTOR times the last stable build's duration (no
+	// limit if there is none), negative means no limit.
+	WaitTimeout time.Duration
+	// Sleep replaces the wait between polls, e.g. with a fake clock in tests
+	Sleep func(ctx context.Context, d time.Duration) error
+	// RequestTimeout bounds each metadata read and POST, but not artifact or
+	// console log downloads, nor triggers that upload files. Zero means
+	// DEFAULT_REQUEST_TIMEOUT, negative means no limit.
+	RequestTimeout time.Duration
+	// Retries is how many times a GET that failed in a retryable way is
+	// repeated, waiting RetryBackoff (DEFAULT_RETRY_BACKOFF when unset) in
+	// between. Retryable replaces IsRetryable in deciding which errors qualify.
+	Retries      int
+	RetryBackoff Backoff
+	Retryable    func(err error) bool
+	// RequestsPerSecond caps the rate of every request the client sends,
+	// reads, triggers and downloads alike; zero means no limit
+	RequestsPerSecond float64
+	// Authorize is called on every request just before it is sent, after any
+	// basic auth and SetHeader headers are attached, e.g. to add a bearer
+	// token from an SSO gateway; an error fails the request
+	Authorize func(req *http.Request) error
+	// Logger receives progress messages; when nil they are discarded
+	Logger Logger
+	// VerifyFingerprints makes GetArtifacts compare each downloaded artifact
+	// against the MD5 Jenkins fingerprinted for it; artifacts without a
+	// fingerprint are not checked
+	VerifyFingerprints bool
+	// permissions for the directories and files GetArtifacts creates, before
+	// the umask; default to DEFAULT_ARTIFACT_DIR_MODE and
+	// DEFAULT_ARTIFACT_FILE_MODE
+	ArtifactDirMode  os.FileMode
+	ArtifactFileMode os.FileMode
+	// ArtifactResults lists the build results whose artifacts may be
+	// downloaded; when empty only successful builds are allowed
+	ArtifactResults []BuildResult
+
+	user    string
+	token   string
+	headers http.Header
+
+	limiter rateLimiter
+
+	crumbLock    sync.Mutex
+	crumbFetched bool
+	crumbField   string
+	crumb        string
+	// the crumb is only valid within the session that issued it
+	crumbCookies []*http.Cookie
 }
 
-func (self *JenkinsInfo) Print() {
-	log.Println("Job Info For", self.Name)
-	log.Println("  description        :", self.Description)
-	log.Println("  url                :", self.Url)
-	log.Println("  buildable          :", self.Buildable)
-	log.Println("  inQueue            :", self.InQueue)
-	log.Println("  lastBuild          :", self.LastBuild)
-	log.Println("  lastBuildUrl       :", self.LastBuildUrl)
-	log.Println("  lastStableBuild    :", self.LastStableBuild)
-	log.Println("  lastStableBuildUrl :", self.LastStableBuildUrl)
+// Logger receives the client's progress messages; *log.Logger satisfies it.
+type Logger interface {
+	Print(v ...interface{})
 }
 
-type JenkinsBuildInfo struct {
-	Name              string
-	ID                int
-	Artifacts         map[string]string
-	Building          bool
-	Duration          float64
-	EstimatedDuration float64
-	Result            string
-	Timestamp         float64
-	Url               string
+type nopLogger struct{}
+
+func (self nopLogger) Print(v ...interface{}) {}
+
+func (self *Client) logger() Logger {
+	if self.Logger == nil {
+		return nopLogger{}
+	}
+	return self.Logger
 }
 
-func (self *JenkinsBuildInfo) Print() {
-	log.Println("Build Info For", self.Name)
-	log.Println("  id                :", self.ID)
-	log.Println("  artifacts         :", self.Artifacts)
-	log.Println("  building          :", self.Building)
-	log.Println("  duration          :", strconv.FormatFloat(self.Duration, 'f', -1, 64))
-	log.Println("  estimatedDuration :", strconv.FormatFloat(self.EstimatedDuration, 'f', -1, 64))
-	log.Println("  result            :", self.Result)
-	log.Println("  timestamp         :", strconv.FormatFloat(self.Timestamp, 'f', -1, 64))
-	log.Println("  url               :", self.Url)
-}
-
-func sanitizeID(name string, id int) (int, error) {
-	if id == -1 {
-		info, err := GetInfo(name)
-		if err != nil {
-			return id, err
+func NewClient(server string) *Client {
+	return &Client{Server: server}
+}
+
+const DEFAULT_POLL_INTERVAL = 1000 * time.Millisecond
+
+const DEFAULT_REQUEST_TIMEOUT = 60 * time.Second
+
+// requestContext bounds a single metadata request by RequestTimeout
+func (self *Client) requestContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	timeout := self.RequestTimeout
+	if timeout == 0 {
+		timeout = DEFAULT_REQUEST_TIMEOUT
+	}
+	if timeout < 0 {
+		return ctx, func() {}
+	}
+	return context.WithTimeout(ctx, timeout)
+}
+
+func (self *Client) pollInterval() time.Duration {
+	if self.PollInterval <= 0 {
+		return DEFAULT_POLL_INTERVAL
+	}
+	return self.PollInterval
+}
+
+// backs the package-level functions, following JENKINS_SERVER and logging to
+// the standard logger
+var defaultClient = &Client{Logger: log.Default()}
+
+func (self *Client) server() string {
+	if self.Server == "" {
+		return JENKINS_SERVER
+	}
+	return self.Server
+}
+
+// SetServer validates and normalizes server before using it, see
+// NormalizeServer.
+func (self *Client) SetServer(server string) error {
+	normalized, err := NormalizeServer(server)
+	if err != nil {
+		return err
+	}
+	self.Server = normalized
+	return nil
+}
+
+// NormalizeServer checks a server address such as "host:8080",
+// "https://ci.example.com/jenkins/" or "host/jenkins" and returns it without
+// the trailing slash, keeping any context path. The scheme is kept only if
+// one was given, and must be http or https.
+func NormalizeServer(server string) (string, error) {
+	server = strings.TrimSpace(server)
+	if server == "" {
+		return "", errors.New("no Jenkins server given")
+	}
+	hasScheme := strings.Contains(server, "://")
+	toParse := server
+	if !hasScheme {
+		toParse = "http://" + server
+	}
+	parsed, err := url.Parse(toParse)
+	if err != nil {
+		return "", errors.New("invalid Jenkins server " + server + ": " + err.Error())
+	}
+	if parsed.Scheme != "http" && parsed.Scheme != "https" {
+		return "", errors.New("invalid Jenkins server " + server + ": scheme must be http or https")
+	}
+	if parsed.User != nil {
+		// not quoting server, which would show the password
+		return "", errors.New("invalid Jenkins server: pass credentials to SetCredentials instead")
+	}
+	if parsed.Host == "" || parsed.RawQuery != "" || parsed.Fragment != "" {
+		return "", errors.New("invalid Jenkins server " + server + ": expected host[:port][/path]")
+	}
+	normalized := parsed.Host + strings.TrimRight(path.Clean("/"+parsed.Path), "/")
+	if hasScheme {
+		normalized = parsed.Scheme + "://" + normalized
+	}
+	return normalized, nil
+}
+
+// SetCredentials makes every request authenticate as user with the given API
+// token (or password) using HTTP basic auth.
+func (self *Client) SetCredentials(user, token string) {
+	self.user = user
+	self.token = token
+}
+
+// SetHeader sends the header with every request, e.g. "Authorization" with
+// "Bearer " and a token for gateways that do not accept basic auth. An empty
+// value stops sending it.
+func (self *Client) SetHeader(key, value string) {
+	if self.headers == nil {
+		self.headers = http.Header{}
+	}
+	if value == "" {
+		self.headers.Del(key)
+	} else {
+		self.headers.Set(key, value)
+	}
+}
+
+// authenticates reports whether requests carry credentials of some kind
+func (self *Client) authenticates() bool {
+	return self.user != "" || self.Authorize != nil || self.headers.Get("Authorization") != ""
+}
+
+// do sends a request with the client's credentials attached
+func (self *Client) do(req *http.Request) (*http.Response, error) {
+	if err := self.waitForRateLimit(req.Context()); err != nil {
+		return nil, err
+	}
+	for key, values := range self.headers {
+		req.Header[key] = append([]string(nil), values...)
+	}
+	if self.user != "" {
+		req.SetBasicAuth(self.user, self.token)
+	}
+	if self.Authorize != nil {
+		if err := self.Authorize(req); err != nil {
+			return nil, err
 		}
-		if info.LastBuild == 0 {
-			return id, errors.New("no build available")
+	}
+	client := self.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+	if client.CheckRedirect == nil {
+		withPolicy := *client
+		withPolicy.CheckRedirect = checkRedirect
+		client = &withPolicy
+	}
+	return client.Do(req)
+}
+
+// the longest redirect chain a GET follows
+const MAX_REDIRECTS = 5
+
+// checkRedirect hands a POST's redirect back unfollowed, so its Location (the
+// queue item, for a trigger) can be read, and caps the chain a GET follows.
+// It only applies when HTTPClient has no CheckRedirect of its own.
+func checkRedirect(req *http.Request, via []*http.Request) error {
+	if via[0].Method == "POST" {
+		return http.ErrUseLastResponse
+	}
+	if len(via) >= MAX_REDIRECTS {
+		return errors.New("stopped after " + strconv.Itoa(MAX_REDIRECTS) + " redirects")
+	}
+	return nil
+}
+
+func (self *Client) postRequest(ctx context.Context, theurl string, form url.Values) (*http.Response, error) {
+	return self.postBody(ctx, theurl, "application/x-www-form-urlencoded", strings.NewReader(form.Encode()))
+}
+
+// postMultipart sends form and files as multipart/form-data, each file as a
+// part named after its key
+func (self *Client) postMultipart(ctx context.Context, theurl string, form url.Values, files map[string]io.Reader) (*http.Response, error) {
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+	for key, values := range form {
+		for _, value := range values {
+			if err := writer.WriteField(key, value); err != nil {
+				return nil, err
+			}
 		}
-		id = info.LastBuild
-	} else if id == -2 {
-		info, err := GetInfo(name)
+	}
+	for key, file := range files {
+		part, err := writer.CreateFormFile(key, key)
 		if err != nil {
-			return id, err
+			return nil, err
 		}
-		if info.LastStableBuild == 0 {
-			return id, errors.New("no stable build available")
+		if _, err := io.Copy(part, file); err != nil {
+			return nil, err
 		}
-		id = info.LastStableBuild
 	}
-	return id, nil
+	if err := writer.Close(); err != nil {
+		return nil, err
+	}
+	return self.postBody(ctx, theurl, writer.FormDataContentType(), body)
 }
 
-func getRemote(theurl string) (io.ReadCloser, error) {
-	//log.Print("Get ", theurl)
-	resp, err := http.Get(theurl)
+func (self *Client) postBody(ctx context.Context, theurl, contentType string, body io.Reader) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, "POST", theurl, body)
 	if err != nil {
 		return nil, err
 	}
-	if resp.StatusCode != 200 {
-		resp.Body.Close()
-		return nil, errors.New("Bad status: " + strconv.Itoa(resp.StatusCode) + " from " + theurl)
+	req.Header.Set("Content-Type", contentType)
+	if err := self.addCrumb(req); err != nil {
+		return nil, err
+	}
+	return self.do(req)
+}
+
+// addCrumb attaches the CSRF crumb, fetching it on first use. Servers without
+// a crumb issuer answer 404, after which requests are sent without one.
+func (self *Client) addCrumb(req *http.Request) error {
+	self.crumbLock.Lock()
+	defer self.crumbLock.Unlock()
+	if !self.crumbFetched {
+		resp, err := self.getResponse(req.Context(), self.url("crumbIssuer", "api", "json"))
+		if isNotFound(err) {
+			self.crumbFetched = true
+		} else if err != nil {
+			return err
+		} else {
+			defer resp.Body.Close()
+			crumb := make(map[string]interface{})
+			if err := json.NewDecoder(resp.Body).Decode(&crumb); err != nil {
+				return err
+			}
+			self.crumb, _ = crumb["crumb"].(string)
+			self.crumbField, _ = crumb["crumbRequestField"].(string)
+			self.crumbCookies = resp.Cookies()
+			self.crumbFetched = true
+		}
+	}
+	if self.crumbField != "" {
+		req.Header.Set(self.crumbField, self.crumb)
+		for _, cookie := range self.crumbCookies {
+			req.AddCookie(cookie)
+		}
+	}
+	return nil
+}
+
+// jobPath turns a slash-separated job name such as "team/service" into the
+// nested path Jenkins serves folders under, "job/team/job/service"
+func jobPath(name string) string {
+	return nestedPath("job", name)
+}
+
+func nestedPath(kind, name string) string {
+	segments := []string{}
+	for _, segment := range strings.Split(name, "/") {
+		if segment != "" {
+			segments = append(segments, kind, segment)
+		}
+	}
+	return path.Join(segments...)
+}
+
+// url builds the address of a Jenkins resource from its path elements
+func (self *Client) url(elem ...string) string {
+	scheme := self.Scheme
+	if scheme == "" {
+		scheme = "http"
+	}
+	host := self.server()
+	if i := strings.Index(host, "://"); i >= 0 {
+		scheme, host = host[:i], host[i+3:]
+	}
+	return scheme + "://" + path.Join(append([]string{host, self.ContextPath}, elem...)...)
+}
+
+type JenkinsInfo struct {
+	Name string `json:"name"`
+	// the name including any folders, e.g. "team/service"
+	FullName               string `json:"fullName"`
+	Description            string `json:"description"`
+	Url                    string `json:"url"`
+	Buildable              bool   `json:"buildable"`
+	InQueue                bool   `json:"inQueue"`
+	LastBuild              int    `json:"lastBuild"`
+	LastBuildUrl           string `json:"lastBuildUrl"`
+	LastStableBuild        int    `json:"lastStableBuild"`
+	LastStableBuildUrl     string `json:"lastStableBuildUrl"`
+	LastSuccessfulBuild    int    `json:"lastSuccessfulBuild"`
+	LastSuccessfulBuildUrl string `json:"lastSuccessfulBuildUrl"`
+	LastFailedBuild        int    `json:"lastFailedBuild"`
+	LastFailedBuildUrl     string `json:"lastFailedBuildUrl"`
+	// only populated by listings that request lastBuild[timestamp]
+	LastBuildTimestamp float64 `json:"lastBuildTimestamp"`
+	// the pending queue item while InQueue
+	QueueID int `json:"queueId"`
+	// Jenkins flags queue items that have waited far too long
+	QueueStuck bool `json:"queueStuck"`
+	// the ball color of the last build, e.g. "blue", "red" or "disabled",
+	// without the "_anime" suffix Jenkins adds while a build runs
+	Color    string `json:"color"`
+	Building bool   `json:"building"`
+	// the weather report: a score from 0 (stormy) to 100 (sunny)
+	HealthScore       int    `json:"healthScore"`
+	HealthDescription string `json:"healthDescription"`
+}
+
+// String returns the lines Print logs, separated by newlines.
+func (self *JenkinsInfo) String() string {
+	return strings.Join(self.textLines(), "\n")
+}
+
+func (self *JenkinsInfo) Print() {
+	for _, line := range self.textLines() {
+		log.Println(line)
+	}
+}
+
+func (self *JenkinsInfo) textLines() []string {
+	return []string{
+		textLine("Job Info For", self.Name),
+		textLine("  description            :", self.Description),
+		textLine("  url                    :", self.Url),
+		textLine("  buildable              :", self.Buildable),
+		textLine("  inQueue                :", self.InQueue),
+		textLine("  lastBuild              :", self.LastBuild),
+		textLine("  lastBuildUrl           :", self.LastBuildUrl),
+		textLine("  lastStableBuild        :", self.LastStableBuild),
+		textLine("  lastStableBuildUrl     :", self.LastStableBuildUrl),
+		textLine("  lastSuccessfulBuild    :", self.LastSuccessfulBuild),
+		textLine("  lastSuccessfulBuildUrl :", self.LastSuccessfulBuildUrl),
+		textLine("  lastFailedBuild        :", self.LastFailedBuild),
+		textLine("  lastFailedBuildUrl     :", self.LastFailedBuildUrl),
+		textLine("  color                  :", self.Color),
+		textLine("  building               :", self.Building),
+		textLine("  health                 :", self.HealthScore, self.HealthDescription),
+	}
+}
+
+type JenkinsBuildInfo struct {
+	Name              string            `json:"name"`
+	ID                int               `json:"id"`
+	Artifacts         map[string]string `json:"artifacts"`
+	Building          bool              `json:"building"`
+	Duration          float64           `json:"duration"`
+	EstimatedDuration float64           `json:"estimatedDuration"`
+	Result            BuildResult       `json:"result"`
+	Timestamp         float64           `json:"timestamp"`
+	Url               string            `json:"url"`
+	BuiltOn           string            `json:"builtOn"`
+	// secret parameter values are REDACTED
+	Parameters map[string]string `json:"parameters"`
+	Causes     []BuildCause      `json:"causes"`
+	Changes    []Change          `json:"changes"`
+	// nil unless the build checked out a Git repository
+	Git *GitInfo `json:"git"`
+	// kept forever, exempt from log rotation
+	KeepLog     bool   `json:"keepLog"`
+	Description string `json:"description"`
+	// the adjacent builds' numbers, 0 when there is none
+	PreviousBuild int `json:"previousBuild"`
+	NextBuild     int `json:"nextBuild"`
+}
+
+// String returns the lines Print logs, separated by newlines.
+func (self *JenkinsBuildInfo) String() string {
+	return strings.Join(self.textLines(), "\n")
+}
+
+func (self *JenkinsBuildInfo) Print() {
+	for _, line := range self.textLines() {
+		log.Println(line)
+	}
+}
+
+func (self *JenkinsBuildInfo) textLines() []string {
+	return []string{
+		textLine("Build Info For", self.Name),
+		textLine("  id                :", self.ID),
+		textLine("  artifacts         :", self.Artifacts),
+		textLine("  building          :", self.Building),
+		textLine("  duration          :", strconv.FormatFloat(self.Duration, 'f', -1, 64)),
+		textLine("  estimatedDuration :", strconv.FormatFloat(self.EstimatedDuration, 'f', -1, 64)),
+		textLine("  result            :", self.Result),
+		textLine("  timestamp         :", strconv.FormatFloat(self.Timestamp, 'f', -1, 64)),
+		textLine("  url               :", self.Url),
+		textLine("  builtOn           :", self.BuiltOn),
+		textLine("  parameters        :", self.Parameters),
+		textLine("  causes            :", self.causeDescriptions()),
+		textLine("  changes           :", self.changeIDs()),
+		textLine("  commit            :", self.gitCommit()),
+		textLine("  keepLog           :", self.KeepLog),
+		textLine("  description       :", self.Description),
+	}
+}
+
+// StartTime is when the build started, or the zero time if unknown.
+func (self *JenkinsBuildInfo) StartTime() time.Time {
+	if self.Timestamp <= 0 {
+		return time.Time{}
+	}
+	return millisToTime(self.Timestamp)
+}
+
+// DurationTime is how long the build took; zero while it runs.
+func (self *JenkinsBuildInfo) DurationTime() time.Duration {
+	return millisToDuration(self.Duration)
+}
+
+// EstimatedDurationTime is Jenkins' estimate of how long the build takes, or
+// zero if it has none.
+func (self *JenkinsBuildInfo) EstimatedDurationTime() time.Duration {
+	if self.EstimatedDuration <= 0 {
+		return 0
+	}
+	return millisToDuration(self.EstimatedDuration)
+}
+
+// EstimatedCompletion is when a running build is expected to finish, going by
+// Jenkins' estimate, or when a finished build actually did. It is the zero
+// time when the start or the estimate is unknown.
+func (self *JenkinsBuildInfo) EstimatedCompletion() time.Time {
+	start := self.StartTime()
+	if start.IsZero() {
+		return start
+	}
+	if !self.Building && self.Duration > 0 {
+		return start.Add(self.DurationTime())
+	}
+	if self.EstimatedDuration <= 0 {
+		return time.Time{}
+	}
+	return start.Add(self.EstimatedDurationTime())
+}
+
+type BuildResult string
+
+const (
+	ResultSuccess  BuildResult = "SUCCESS"
+	ResultFailure  BuildResult = "FAILURE"
+	ResultUnstable BuildResult = "UNSTABLE"
+	ResultAborted  BuildResult = "ABORTED"
+	ResultNotBuilt BuildResult = "NOT_BUILT"
+	ResultBuilding BuildResult = "BUILDING"
+	// not started yet
+	ResultPending BuildResult = "PENDING"
+)
+
+// ParseBuildResult converts the result and building fields of a build's json
+// into a BuildResult. The result is null until the build finishes, both while
+// it runs and before it has started.
+func ParseBuildResult(result interface{}, building bool) BuildResult {
+	if result == nil {
+		if building {
+			return ResultBuilding
+		}
+		return ResultPending
+	}
+	str, _ := result.(string)
+	return BuildResult(str)
+}
+
+func (self BuildResult) String() string {
+	return string(self)
+}
+
+// finished reports whether the build has a final result; a build that is
+// neither building nor done has not started yet
+func (self *JenkinsBuildInfo) finished() bool {
+	return !self.Building && self.Result != ResultPending
+}
+
+// build IDs that resolve to the job's permalinks
+const (
+	LAST_BUILD            = -1
+	LAST_STABLE_BUILD     = -2
+	LAST_SUCCESSFUL_BUILD = -3
+	LAST_FAILED_BUILD     = -4
+)
+
+func (self *Client) sanitizeID(ctx context.Context, name string, id int) (int, error) {
+	id, _, err := self.resolveID(ctx, name, id)
+	return id, err
+}
+
+// resolveID is sanitizeID that also returns the job info it loaded to resolve
+// a sentinel ID, so callers needing it can skip fetching it again; the info is
+// nil when id was already a build number
+func (self *Client) resolveID(ctx context.Context, name string, id int) (int, *JenkinsInfo, error) {
+	if id >= 0 {
+		return id, nil, nil
+	}
+	info, err := self.GetInfoContext(ctx, name)
+	if err != nil {
+		return id, nil, err
+	}
+	resolved, what := 0, ""
+	switch id {
+	case LAST_BUILD:
+		resolved, what = info.LastBuild, "build"
+	case LAST_STABLE_BUILD:
+		resolved, what = info.LastStableBuild, "stable build"
+	case LAST_SUCCESSFUL_BUILD:
+		resolved, what = info.LastSuccessfulBuild, "successful build"
+	case LAST_FAILED_BUILD:
+		resolved, what = info.LastFailedBuild, "failed build"
+	default:
+		return id, nil, errors.New("unknown build id " + strconv.Itoa(id))
+	}
+	if resolved == 0 {
+		return id, nil, errors.New("no " + what + " available")
+	}
+	return resolved, info, nil
+}
+
+func (self *Client) getRemote(ctx context.Context, theurl string) (io.ReadCloser, error) {
+	resp, err := self.getResponse(ctx, theurl)
+	if err != nil {
+		return nil, err
 	}
 	return resp.Body, nil
 }
 
-func get(name string, id int) (map[string]interface{}, error) {
-	// build URL
-	nameAndID := name
-	if id > 0 {
-		nameAndID = path.Join(name, strconv.Itoa(id))
+func (self *Client) getOnce(ctx context.Context, theurl string, offset int64) (*http.Response, error) {
+	//log.Print("Get ", theurl)
+	req, err := http.NewRequestWithContext(ctx, "GET", theurl, nil)
+	if err != nil {
+		return nil, err
+	}
+	if offset > 0 {
+		req.Header.Set("Range", "bytes="+strconv.FormatInt(offset, 10)+"-")
+		// offsets count the stored bytes, not the compressed ones
+		req.Header.Set("Accept-Encoding", "identity")
+	} else {
+		req.Header.Set("Accept-Encoding", "gzip")
 	}
-	theurl := "http://" + path.Join(JENKINS_SERVER, "job", nameAndID, "api", "json")
-	resp, err := getRemote(theurl)
+	resp, err := self.do(req)
 	if err != nil {
 		return nil, err
 	}
-	defer resp.Close()
-	jsonDecoder := json.NewDecoder(resp)
+	if err := gunzipBody(resp); err != nil {
+		resp.Body.Close()
+		return nil, err
+	}
+	if resp.StatusCode != 200 && !(offset > 0 && resp.StatusCode == http.StatusPartialContent) {
+		return nil, newHTTPError(resp, theurl)
+	}
+	return resp, nil
+}
+
+func (self *Client) getJSON(ctx context.Context, theurl string) (map[string]interface{}, error) {
 	retVal := make(map[string]interface{})
-	errJson := jsonDecoder.Decode(&retVal)
-	if errJson != nil {
-		return nil, errJson
+	if err := self.decodeJSON(ctx, theurl, &retVal); err != nil {
+		return nil, err
 	}
 	return retVal, nil
 }
 
-func post(name string, action string, params string) error {
-	theurl := "http://" + path.Join(JENKINS_SERVER, "job", name, "buildWithParameters") + "?token=" + name + "-token"
-	form, err := url.ParseQuery(params)
+func (self *Client) decodeJSON(ctx context.Context, theurl string, v interface{}) error {
+	ctx, cancel := self.requestContext(ctx)
+	defer cancel()
+	resp, err := self.getRemote(ctx, theurl)
 	if err != nil {
 		return err
 	}
-	resp, err := http.PostForm(theurl, form)
+	defer resp.Close()
+	jsonDecoder := json.NewDecoder(resp)
+	return jsonDecoder.Decode(v)
+}
+
+type BuildOptions struct {
+	// Cause is shown in the build history instead of "Started by remote host".
+	// Jenkins only reads it on triggers authorized by a token, so a client
+	// with credentials also needs Token to send one.
+	Cause string
+	// CheckRequiredParams refuses to trigger when a parameter without a
+	// default is missing from params, at the cost of an extra request.
+	CheckRequiredParams bool
+	// ValidateParams refuses to trigger with parameters the job does not
+	// define, or with a value a boolean or choice parameter does not allow.
+	ValidateParams bool
+	// Context cancels the trigger and, when waiting, the wait for the build.
+	Context context.Context
+	// Token is the job's remote trigger token. When empty no token is sent if
+	// the client has credentials, and the job name followed by "-token" is
+	// sent otherwise.
+	Token string
+	// Files are uploaded as file parameters, keyed by parameter name; the
+	// trigger is then sent as multipart/form-data.
+	Files map[string]io.Reader
+	// FailIfQueued makes a trigger for a job that already has a build in the
+	// queue return ErrAlreadyQueued, rather than nothing or, when waiting, the
+	// queued build's result; either way no new build is scheduled.
+	FailIfQueued bool
+}
+
+// returned by DoBuildWithOptions with FailIfQueued when the job was already
+// queued
+var ErrAlreadyQueued = errors.New("job already in queue")
+
+// triggers the build and returns the URL of its queue item
+func (self *Client) post(ctx context.Context, name string, params string, opts *BuildOptions) (string, error) {
+	form, err := url.ParseQuery(params)
+	if err != nil {
+		// the parse error quotes the offending value, which may be a secret
+		return "", errors.New("invalid build parameters")
+	}
+	return self.postBuild(ctx, name, form, opts)
+}
+
+// buildToken picks the remote trigger token: the one in opts, none when the
+// client authenticates, and otherwise the job name followed by "-token".
+func (self *Client) buildToken(name string, opts *BuildOptions) string {
+	if opts != nil && opts.Token != "" {
+		return opts.Token
+	}
+	if self.authenticates() {
+		return ""
+	}
+	return name + "-token"
+}
+
+// triggers the build and returns the queue item URL Jenkins reports for it.
+// Jobs without parameters reject buildWithParameters, so an empty form is
+// sent to build first; parameterized jobs answer that with 400, and are run
+// with their defaults through buildWithParameters instead.
+func (self *Client) postBuild(ctx context.Context, name string, form url.Values, opts *BuildOptions) (string, error) {
+	var files map[string]io.Reader
+	if opts != nil {
+		files = opts.Files
+	}
+	if opts != nil && opts.Cause != "" && self.buildToken(name, opts) == "" {
+		return "", errors.New("a build cause needs BuildOptions.Token when the client authenticates")
+	}
+	if len(files) == 0 {
+		// uploads take as long as the files do, like artifact downloads
+		var cancel context.CancelFunc
+		ctx, cancel = self.requestContext(ctx)
+		defer cancel()
+	}
+	actions := []string{"buildWithParameters"}
+	if len(form) == 0 && len(files) == 0 {
+		actions = []string{"build", "buildWithParameters"}
+	}
+	for i, action := range actions {
+		query := url.Values{}
+		if token := self.buildToken(name, opts); token != "" {
+			query.Set("token", token)
+		}
+		if opts != nil && opts.Cause != "" {
+			query.Set("cause", opts.Cause)
+		}
+		theurl := self.url(jobPath(name), action)
+		if len(query) > 0 {
+			theurl += "?" + query.Encode()
+		}
+		var resp *http.Response
+		var err error
+		if len(files) > 0 {
+			resp, err = self.postMultipart(ctx, theurl, form, files)
+		} else {
+			resp, err = self.postRequest(ctx, theurl, form)
+		}
+		if err != nil {
+			return "", err
+		}
+		if resp.StatusCode == 400 && i < len(actions)-1 {
+			resp.Body.Close()
+			continue
+		}
+		if resp.StatusCode >= 400 {
+			// without the query, which carries the trigger token
+			return "", newHTTPError(resp, self.url(jobPath(name), action))
+		}
+		resp.Body.Close()
+		// resolved against the trigger URL in case a proxy rewrote it to a
+		// relative one
+		location, err := resp.Location()
+		if err != nil {
+			return "", nil
+		}
+		return location.String(), nil
+	}
+	return "", nil
+}
+
+func (self *Client) postForm(ctx context.Context, theurl string, form url.Values) error {
+	ctx, cancel := self.requestContext(ctx)
+	defer cancel()
+	resp, err := self.postRequest(ctx, theurl, form)
 	if err != nil {
 		return err
 	}
+	if resp.StatusCode >= 400 {
+		return newHTTPError(resp, theurl)
+	}
 	resp.Body.Close()
 	return nil
 }
 
-func DoBuild(name, params string, wait bool) (*JenkinsBuildInfo, error) {
-	log.Print("Building ", name)
-	info, err := GetInfo(name)
+func (self *Client) DoBuild(name, params string, wait bool) (*JenkinsBuildInfo, error) {
+	return self.DoBuildWithOptions(name, params, wait, nil)
+}
+
+func (self *Client) DoBuildContext(ctx context.Context, name, params string, wait bool) (*JenkinsBuildInfo, error) {
+	return self.DoBuildWithOptions(name, params, wait, &BuildOptions{Context: ctx})
+}
+
+// DoBuildWithParams is DoBuild with the parameters given as a map, which is
+// form-encoded for the caller.
+func (self *Client) DoBuildWithParams(name string, params map[string]string, wait bool) (*JenkinsBuildInfo, error) {
+	return self.DoBuild(name, paramsForm(params).Encode(), wait)
+}
+
+func (self *Client) DoBuildWithOptions(name, params string, wait bool, opts *BuildOptions) (*JenkinsBuildInfo, error) {
+	if opts == nil {
+		opts = &BuildOptions{}
+	}
+	ctx := opts.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	self.logger().Print("Building ", name)
+	if opts.CheckRequiredParams {
+		if err := self.checkRequiredParams(name, params); err != nil {
+			return nil, err
+		}
+	}
+	if opts.ValidateParams {
+		provided, err := parseParams(params)
+		if err != nil {
+			return nil, err
+		}
+		if err := self.ValidateParams(name, provided); err != nil {
+			return nil, err
+		}
+	}
+	info, err := self.GetInfoContext(ctx, name)
 	if err != nil {
 		return nil, err
 	}
-	newBuild := info.LastBuild + 1
+	var queueURL string
 	if info.InQueue {
-		log.Print("Job already in queue.")
+		self.logger().Print("Job already in queue.")
+		if opts.FailIfQueued {
+			return nil, ErrAlreadyQueued
+		}
+		queueURL = self.url("queue", "item", strconv.Itoa(info.QueueID))
 	} else {
-		err := post(name, "buildWithParameters", params)
+		queueURL, err = self.post(ctx, name, params, opts)
 		if err != nil {
 			return nil, err
 		}
-		log.Print("Build #", newBuild, " scheduled.")
+		self.logger().Print("Build scheduled.")
 	}
 	if !wait {
 		return nil, nil
 	}
-	binfo, err := GetBuildInfo(name, info.LastStableBuild)
+	lastStableMillis, err := self.lastStableDuration(ctx, name, info)
 	if err != nil {
 		return nil, errors.New("Couldn't fetch last stable build info")
 	}
-	log.Print("Waiting for job to complete. Last stable took ",
-		strconv.FormatFloat(binfo.Duration, 'f', -1, 64), " milliseconds.")
+	self.logger().Print("Waiting for job to complete. Last stable took ",
+		strconv.FormatFloat(lastStableMillis, 'f', -1, 64), " milliseconds.")
+	waitCtx, timeout, cancel := self.waitContext(ctx, lastStableMillis)
+	defer cancel()
+	state := "queued"
+	newBuild, err := self.waitForQueuedBuild(waitCtx, queueURL)
+	if err != nil {
+		return nil, waitError(ctx, waitCtx, timeout, name, state, err)
+	}
+	self.logger().Print("Build #", newBuild, " left the queue.")
+	state = "build #" + strconv.Itoa(newBuild) + " starting"
+	binfo, err := self.pollBuild(waitCtx, name, newBuild, lastStableMillis, &state)
+	if err != nil {
+		return nil, waitError(ctx, waitCtx, timeout, name, state, err)
+	}
+	return binfo, nil
+}
+
+// WaitForBuild blocks until a build that was started by some other means
+// finishes, polling like DoBuild and bounded by the same WaitTimeout.
+func (self *Client) WaitForBuild(name string, id int) (*JenkinsBuildInfo, error) {
+	return self.WaitForBuildContext(context.Background(), name, id)
+}
+
+func (self *Client) WaitForBuildContext(ctx context.Context, name string, id int) (*JenkinsBuildInfo, error) {
+	id, info, err := self.resolveID(ctx, name, id)
+	if err != nil {
+		return nil, err
+	}
+	if info == nil {
+		if info, err = self.GetInfoContext(ctx, name); err != nil {
+			return nil, err
+		}
+	}
+	lastStableMillis, err := self.lastStableDuration(ctx, name, info)
+	if err != nil {
+		return nil, err
+	}
+	waitCtx, timeout, cancel := self.waitContext(ctx, lastStableMillis)
+	defer cancel()
+	state := "build #" + strconv.Itoa(id) + " starting"
+	binfo, err := self.pollBuild(waitCtx, name, id, lastStableMillis, &state)
+	if err != nil {
+		return nil, waitError(ctx, waitCtx, timeout, name, state, err)
+	}
+	return binfo, nil
+}
+
+// lastStableDuration returns how long the job's last stable build took, or 0
+// if it has none
+func (self *Client) lastStableDuration(ctx context.Context, name string, info *JenkinsInfo) (float64, error) {
+	if info.LastStableBuild <= 0 {
+		return 0, nil
+	}
+	binfo, err := self.GetBuildInfoContext(ctx, name, info.LastStableBuild)
+	if err != nil {
+		return 0, err
+	}
+	return binfo.Duration, nil
+}
+
+// pollBuild waits for build id to finish, keeping state up to date for the
+// timeout message
+func (self *Client) pollBuild(ctx context.Context, name string, id int, lastStableMillis float64, state *string) (*JenkinsBuildInfo, error) {
+	if self.DelayFirstPoll {
+		if err := self.sleep(ctx, millisToDuration(lastStableMillis)); err != nil {
+			return nil, err
+		}
+	}
+	backoff := self.pollBackoff()
 	inQueue := false
 	building := false
 	weird := false
 	for {
-		binfo, err = GetBuildInfo(name, newBuild)
-		if err == nil && !binfo.Building {
+		binfo, err := self.GetBuildInfoContext(ctx, name, id)
+		if err == nil && binfo.finished() {
 			return binfo, nil
+		} else if ctx.Err() != nil {
+			return nil, ctx.Err()
 		} else if err != nil {
-			info, err := GetInfo(name)
-			if err != nil {
-				return nil, err
+			info, errInfo := self.GetInfoContext(ctx, name)
+			if errInfo != nil {
+				return nil, errInfo
 			}
-			if !info.InQueue || info.LastBuild+1 != newBuild {
+			if !info.InQueue || info.LastBuild+1 != id {
 				// huh? thats weird. maybe something crazy happened. lets do one more pass
 				if weird {
-					return nil, errors.New("weird state. could not wait for job to complete.")
-					weird = true
+					return nil, errors.New("weird state. could not wait for job to complete: " + err.Error())
 				}
+				weird = true
+			} else {
+				weird = false
 			}
 			if info.InQueue {
 				if !inQueue {
-					log.Print("Job is in queue.")
+					self.logger().Print("Job is in queue.")
 					inQueue = true
+					backoff.Reset()
 				}
 			}
 		} else if binfo.Building {
+			weird = false
 			if !building {
-				log.Print("Job is building.")
+				self.logger().Print("Job is building.")
 				building = true
+				*state = "build #" + strconv.Itoa(id) + " building"
+				backoff.Reset()
 			}
 		}
-		time.Sleep(1000 * time.Millisecond)
+		if err := self.sleep(ctx, backoff.Next()); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// waitContext bounds ctx by the wait timeout for a job whose last stable
+// build took lastStableMillis
+func (self *Client) waitContext(ctx context.Context, lastStableMillis float64) (context.Context, time.Duration, context.CancelFunc) {
+	timeout := self.waitTimeout(lastStableMillis)
+	if timeout <= 0 {
+		return ctx, 0, func() {}
+	}
+	waitCtx, cancel := context.WithTimeout(ctx, timeout)
+	return waitCtx, timeout, cancel
+}
+
+// waitError reports running out of time as such, along with the last state
+// seen, rather than as whatever request the deadline interrupted
+func waitError(ctx, waitCtx context.Context, timeout time.Duration, name, state string, err error) error {
+	if ctx.Err() == nil && waitCtx.Err() == context.DeadlineExceeded {
+		return errors.New("timed out after " + timeout.String() + " waiting for " + name + " (last state: " + state + ")")
+	}
+	return err
+}
+
+// the default wait is this many times the last stable build's duration
+const WAIT_TIMEOUT_FACTOR = 10
+
+func (self *Client) waitTimeout(lastStableMillis float64) time.Duration {
+	if self.WaitTimeout != 0 {
+		if self.WaitTimeout < 0 {
+			return 0
+		}
+		return self.WaitTimeout
 	}
-	// TODO: wait for build to finish and return the info
-	return nil, nil
+	return millisToDuration(WAIT_TIMEOUT_FACTOR * lastStableMillis)
 }
 
-func GetArtifactReader(name string, id int, artifact string) (io.ReadCloser, error) {
-	info, err := GetBuildInfo(name, id)
+func (self *Client) GetArtifactReader(name string, id int, artifact string) (io.ReadCloser, error) {
+	return self.GetArtifactReaderContext(context.Background(), name, id, artifact)
+}
+
+func (self *Client) GetArtifactReaderContext(ctx context.Context, name string, id int, artifact string) (io.ReadCloser, error) {
+	// a sentinel ID resolves along with the build info, in one request
+	info, err := self.GetBuildInfoContext(ctx, name, id)
 	if err != nil {
 		return nil, err
 	}
-	if info.Result != "SUCCESS" {
-		return nil, errors.New("the build you requested failed")
+	if err := self.checkArtifactResult(info); err != nil {
+		return nil, err
+	}
+	id = info.ID
+	inpath, ok := info.Artifacts[artifact]
+	if !ok {
+		return nil, errors.New("build #" + strconv.Itoa(id) + " of " + name + " has no artifact " + artifact)
 	}
-	nameAndID := path.Join(name, strconv.Itoa(id))
-	url := "http://" + path.Join(JENKINS_SERVER, "job", nameAndID, "artifact", info.Artifacts[artifact])
-	return getRemote(url)
+	nameAndID := path.Join(jobPath(name), strconv.Itoa(id))
+	url := self.url(nameAndID, "artifact", inpath)
+	return self.getRemote(ctx, url)
 }
 
-func GetArtifacts(name string, id int, output string) ([]string, error) {
-	log.Print("Fetching ", name, " to ", output)
-	id, err := sanitizeID(name, id)
+func (self *Client) checkArtifactResult(info *JenkinsBuildInfo) error {
+	allowed := self.ArtifactResults
+	if len(allowed) == 0 {
+		allowed = []BuildResult{ResultSuccess}
+	}
+	for _, result := range allowed {
+		if info.Result == result {
+			return nil
+		}
+	}
+	return errors.New("the build you requested failed (" + info.Result.String() + ")")
+}
+
+const DEFAULT_ARTIFACT_CONCURRENCY = 4
+
+const (
+	DEFAULT_ARTIFACT_DIR_MODE  os.FileMode = 0755
+	DEFAULT_ARTIFACT_FILE_MODE os.FileMode = 0666
+)
+
+func (self *Client) artifactDirMode() os.FileMode {
+	if self.ArtifactDirMode == 0 {
+		return DEFAULT_ARTIFACT_DIR_MODE
+	}
+	return self.ArtifactDirMode.Perm()
+}
+
+func (self *Client) artifactFileMode() os.FileMode {
+	if self.ArtifactFileMode == 0 {
+		return DEFAULT_ARTIFACT_FILE_MODE
+	}
+	return self.ArtifactFileMode.Perm()
+}
+
+type ArtifactOptions struct {
+	// Tee, if set, is called once per artifact with its output path. A non-nil
+	// writer receives a copy of the artifact's bytes as they are written to
+	// disk, e.g. to compute a checksum without a second read pass. Artifacts
+	// download concurrently, so Tee may be called from several goroutines.
+	Tee func(outpath string) io.Writer
+	// Patterns selects artifacts by path.Match globs against their display
+	// path; when empty every artifact is downloaded
+	Patterns []string
+	// Progress, if set, is called as each artifact downloads with the bytes
+	// written so far and its total size, or -1 when the server does not
+	// report one. Like Tee it may be called from several goroutines.
+	Progress func(outpath string, downloaded, total int64)
+	// Resume continues a partially downloaded file with a Range request
+	// instead of starting over. If the server ignores the range, the file is
+	// downloaded again in full. Tee only sees the bytes fetched this time.
+	Resume bool
+	// Concurrency is the number of artifacts downloaded at once; defaults to
+	// DEFAULT_ARTIFACT_CONCURRENCY
+	Concurrency int
+	// Context bounds the whole download; Timeout, if set, is applied on top.
+	Context context.Context
+	Timeout time.Duration
+	// FileTimeout bounds the download of each individual artifact.
+	FileTimeout time.Duration
+	// ContinueOnError keeps downloading the remaining artifacts when one
+	// fails. The failures are returned together as ArtifactErrors.
+	ContinueOnError bool
+}
+
+type ArtifactError struct {
+	Path string
+	Err  error
+}
+
+type ArtifactErrors []ArtifactError
+
+func (self ArtifactErrors) Error() string {
+	msg := strconv.Itoa(len(self)) + " artifacts failed:"
+	for _, failure := range self {
+		msg += " " + failure.Path + ": " + failure.Err.Error() + ";"
+	}
+	return msg
+}
+
+// GetArtifacts downloads the build's artifacts into output. If patterns are
+// given only artifacts whose display path matches one of them are fetched.
+func (self *Client) GetArtifacts(name string, id int, output string, patterns ...string) ([]string, error) {
+	return self.GetArtifactsWithOptions(name, id, output, &ArtifactOptions{Patterns: patterns})
+}
+
+func (self *Client) GetArtifactsContext(ctx context.Context, name string, id int, output string) ([]string, error) {
+	return self.GetArtifactsWithOptions(name, id, output, &ArtifactOptions{Context: ctx})
+}
+
+func (self *Client) GetArtifactsWithOptions(name string, id int, output string, opts *ArtifactOptions) ([]string, error) {
+	if opts == nil {
+		opts = &ArtifactOptions{}
+	}
+	ctx := opts.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if opts.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, opts.Timeout)
+		defer cancel()
+	}
+	self.logger().Print("Fetching ", name, " to ", output)
+	info, err := self.GetBuildInfoContext(ctx, name, id)
 	if err != nil {
 		return nil, err
 	}
-	info, err := GetBuildInfo(name, id)
-	if err != nil {
+	if err := self.checkArtifactResult(info); err != nil {
 		return nil, err
 	}
-	if info.Result != "SUCCESS" {
-		return nil, errors.New("the build you requested failed")
+	id = info.ID
+	nameAndID := path.Join(jobPath(name), strconv.Itoa(id))
+	workers := opts.Concurrency
+	if workers <= 0 {
+		workers = DEFAULT_ARTIFACT_CONCURRENCY
 	}
-	nameAndID := path.Join(name, strconv.Itoa(id))
+	// cancelled on the first fatal error so no further downloads start
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	var lock sync.Mutex
+	var firstErr error
 	artifacts := []string{}
-	log.Print("Fetching artifacts for build #", id, " (", len(info.Artifacts), " total)")
-	for outpath, inpath := range info.Artifacts {
-		url := "http://" + path.Join(JENKINS_SERVER, "job", nameAndID, "artifact", inpath)
-		artifact, err := getRemote(url)
+	failures := ArtifactErrors{}
+	selected, err := selectArtifacts(info.Artifacts, opts.Patterns)
+	if err != nil {
+		return nil, err
+	}
+	hashes := map[string]string{}
+	if self.VerifyFingerprints {
+		fingerprints, err := self.getFingerprints(ctx, nameAndID)
 		if err != nil {
-			return artifacts, err
+			return nil, err
 		}
-		defer artifact.Close()
-
-		dir := path.Join(output, path.Dir(outpath))
-		errMkdir := os.MkdirAll(dir, os.ModeDir|0755)
-		if errMkdir != nil {
-			return artifacts, errMkdir
+		relativePaths := make([]string, 0, len(info.Artifacts))
+		for _, inpath := range info.Artifacts {
+			relativePaths = append(relativePaths, inpath)
 		}
-		fo, errFo := os.Create(path.Join(output, outpath))
-		if errFo != nil {
-			return artifacts, errFo
+		hashes = artifactHashes(fingerprints, relativePaths)
+	}
+	self.logger().Print("Fetching artifacts for build #", id, " (", len(selected), " of ", len(info.Artifacts), " total)")
+	outpaths := make(chan string)
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for outpath := range outpaths {
+				inpath := info.Artifacts[outpath]
+				url := self.url(nameAndID, "artifact", inpath)
+				err := self.fetchArtifact(ctx, url, output, outpath, hashes[inpath], opts)
+				lock.Lock()
+				if err == nil {
+					artifacts = append(artifacts, path.Join(output, outpath))
+				} else if opts.ContinueOnError && ctx.Err() == nil {
+					self.logger().Print("Failed to fetch ", outpath, ": ", err)
+					failures = append(failures, ArtifactError{outpath, err})
+				} else if firstErr == nil {
+					firstErr = err
+					cancel()
+				}
+				lock.Unlock()
+			}
+		}()
+	}
+schedule:
+	for _, outpath := range selected {
+		select {
+		case outpaths <- outpath:
+		case <-ctx.Done():
+			break schedule
 		}
-		defer fo.Close()
-		log.Print("-> ", path.Join(output, outpath))
-		io.Copy(fo, artifact)
+	}
+	close(outpaths)
+	wg.Wait()
+	if firstErr != nil {
+		return artifacts, firstErr
+	}
+	if ctx.Err() != nil {
+		return artifacts, ctx.Err()
+	}
+	if len(failures) > 0 {
+		return artifacts, failures
 	}
 	return artifacts, nil
 }
 
-func GetBuildInfo(name string, id int) (*JenkinsBuildInfo, error) {
-	id, err := sanitizeID(name, id)
+func selectArtifacts(artifacts map[string]string, patterns []string) ([]string, error) {
+	selected := []string{}
+	for outpath := range artifacts {
+		if len(patterns) == 0 {
+			selected = append(selected, outpath)
+			continue
+		}
+		for _, pattern := range patterns {
+			matched, err := path.Match(pattern, outpath)
+			if err != nil {
+				return nil, errors.New("bad artifact pattern " + pattern + ": " + err.Error())
+			}
+			if matched {
+				selected = append(selected, outpath)
+				break
+			}
+		}
+	}
+	return selected, nil
+}
+
+// fetchArtifact downloads url to outpath under output, checking the bytes
+// against md5sum unless it is empty
+func (self *Client) fetchArtifact(ctx context.Context, url, output, outpath, md5sum string, opts *ArtifactOptions) error {
+	fileCtx := ctx
+	if opts.FileTimeout > 0 {
+		var cancel context.CancelFunc
+		fileCtx, cancel = context.WithTimeout(ctx, opts.FileTimeout)
+		defer cancel()
+	}
+	if err := checkArtifactPath(outpath); err != nil {
+		return err
+	}
+	outfile := path.Join(output, outpath)
+	offset := int64(0)
+	if opts.Resume {
+		if stat, err := os.Stat(outfile); err == nil && stat.Mode().IsRegular() {
+			offset = stat.Size()
+		}
+	}
+	resp, err := self.getResponseFrom(fileCtx, url, offset)
+	if offset > 0 && isStatus(err, http.StatusRequestedRangeNotSatisfiable) {
+		// the file is already as long as the artifact; fetch it again rather
+		// than trust it
+		offset = 0
+		resp, err = self.getResponse(fileCtx, url)
+	}
 	if err != nil {
-		return nil, err
+		return fileTimeoutError(ctx, fileCtx, opts, err)
+	}
+	artifact := resp.Body
+	defer artifact.Close()
+	if resp.StatusCode != http.StatusPartialContent {
+		offset = 0
+	}
+
+	dir := path.Join(output, path.Dir(outpath))
+	errMkdir := os.MkdirAll(dir, self.artifactDirMode())
+	if errMkdir != nil {
+		return errMkdir
+	}
+	hash := md5.New()
+	if md5sum != "" && offset > 0 {
+		if err := hashPrefix(hash, outfile, offset); err != nil {
+			return err
+		}
+	}
+	flags := os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+	if offset > 0 {
+		flags = os.O_WRONLY | os.O_APPEND
+		self.logger().Print("-> ", outfile, " (resuming at ", offset, " bytes)")
+	} else {
+		self.logger().Print("-> ", outfile)
+	}
+	fo, errFo := os.OpenFile(outfile, flags, self.artifactFileMode())
+	if errFo != nil {
+		return errFo
+	}
+	dst := []io.Writer{fo}
+	if opts.Tee != nil {
+		if tee := opts.Tee(outpath); tee != nil {
+			dst = append(dst, tee)
+		}
 	}
-	json, err := get(name, id)
-	if err != nil || json == nil {
+	if md5sum != "" {
+		dst = append(dst, hash)
+	}
+	if opts.Progress != nil {
+		total := resp.ContentLength
+		if total >= 0 {
+			total += offset
+		}
+		dst = append(dst, &progressWriter{outpath, offset, total, opts.Progress})
+	}
+	_, errCopy := io.Copy(io.MultiWriter(dst...), artifact)
+	// a failed close can lose buffered writes, so it fails the download too
+	errClose := fo.Close()
+	if errCopy != nil {
+		return fileTimeoutError(ctx, fileCtx, opts, errCopy)
+	}
+	if errClose != nil {
+		return errClose
+	}
+	if md5sum != "" {
+		if sum := hex.EncodeToString(hash.Sum(nil)); sum != md5sum {
+			if offset > 0 {
+				// don't resume from a corrupt partial file again
+				os.Remove(outfile)
+			}
+			return errors.New("fingerprint mismatch for " + outpath + ": got " + sum + ", expected " + md5sum)
+		}
+	}
+	return nil
+}
+
+// progressWriter reports the running byte count of an artifact download
+type progressWriter struct {
+	outpath    string
+	downloaded int64
+	total      int64
+	report     func(outpath string, downloaded, total int64)
+}
+
+func (self *progressWriter) Write(p []byte) (int, error) {
+	self.downloaded += int64(len(p))
+	self.report(self.outpath, self.downloaded, self.total)
+	return len(p), nil
+}
+
+// checkArtifactPath rejects server-provided paths that would write outside
+// the output directory
+func checkArtifactPath(outpath string) error {
+	cleaned := path.Clean(strings.ReplaceAll(outpath, "\\", "/"))
+	if path.IsAbs(cleaned) || cleaned == ".." || strings.HasPrefix(cleaned, "../") {
+		return errors.New("refusing to write artifact outside the output directory: " + outpath)
+	}
+	return nil
+}
+
+// hashPrefix feeds the first n bytes of file to hash
+func hashPrefix(hash io.Writer, file string, n int64) error {
+	fi, err := os.Open(file)
+	if err != nil {
+		return err
+	}
+	defer fi.Close()
+	_, err = io.CopyN(hash, fi, n)
+	return err
+}
+
+// reports a per-file deadline as such rather than as a generic read error
+func fileTimeoutError(ctx, fileCtx context.Context, opts *ArtifactOptions, err error) error {
+	if ctx.Err() == nil && fileCtx.Err() == context.DeadlineExceeded {
+		return errors.New("timed out after " + opts.FileTimeout.String())
+	}
+	return err
+}
+
+func (self *Client) GetBuildInfo(name string, id int) (*JenkinsBuildInfo, error) {
+	return self.GetBuildInfoContext(context.Background(), name, id)
+}
+
+func (self *Client) GetBuildInfoContext(ctx context.Context, name string, id int) (*JenkinsBuildInfo, error) {
+	return self.getBuildInfo(ctx, name, id)
+}
+
+// DescribeBuild returns everything known about a build, including its
+// parameters, causes and changes, from a single request that asks only for
+// those fields. GetBuildInfo asks for the same tree, so both return the
+// whole build.
+func (self *Client) DescribeBuild(name string, id int) (*JenkinsBuildInfo, error) {
+	return self.GetBuildInfo(name, id)
+}
+
+// GetLastBuildInfo fetches the job's most recent build in a single request,
+// where GetBuildInfo with LAST_BUILD would first look up its number.
+func (self *Client) GetLastBuildInfo(name string) (*JenkinsBuildInfo, error) {
+	return self.getBuildInfo(context.Background(), name, LAST_BUILD)
+}
+
+func (self *Client) GetLastStableBuildInfo(name string) (*JenkinsBuildInfo, error) {
+	return self.getBuildInfo(context.Background(), name, LAST_STABLE_BUILD)
+}
+
+func (self *Client) GetLastSuccessfulBuildInfo(name string) (*JenkinsBuildInfo, error) {
+	return self.getBuildInfo(context.Background(), name, LAST_SUCCESSFUL_BUILD)
+}
+
+func (self *Client) getBuildInfo(ctx context.Context, name string, id int) (*JenkinsBuildInfo, error) {
+	build, what := strconv.Itoa(id), ""
+	if id < 0 {
+		var err error
+		if build, what, err = permalink(id); err != nil {
+			return nil, err
+		}
+	}
+	resp := buildResponse{}
+	err := self.getChecked(ctx, name, build, describeTree, buildFields, &resp)
+	if id < 0 && isNotFound(err) {
+		// the permalink is missing both when the job is and when it has no
+		// such build yet
+		if exists, errExists := self.jobExists(ctx, name); errExists == nil && exists {
+			return nil, errors.New("no " + what + " available")
+		}
+	}
+	if err != nil {
 		return nil, err
 	}
+	return parseBuild(&resp), nil
+}
+
+// permalink returns the path and description of the build a sentinel ID
+// refers to
+func permalink(id int) (string, string, error) {
+	switch id {
+	case LAST_BUILD:
+		return "lastBuild", "build", nil
+	case LAST_STABLE_BUILD:
+		return "lastStableBuild", "stable build", nil
+	case LAST_SUCCESSFUL_BUILD:
+		return "lastSuccessfulBuild", "successful build", nil
+	case LAST_FAILED_BUILD:
+		return "lastFailedBuild", "failed build", nil
+	}
+	return "", "", errors.New("unknown build id " + strconv.Itoa(id))
+}
+
+func parseBuild(resp *buildResponse) *JenkinsBuildInfo {
 	info := JenkinsBuildInfo{}
-	info.Name, _ = json["fullDisplayName"].(string)
-	idF64, _ := json["number"].(float64)
-	info.ID = int(idF64)
-	artifacts, _ := json["artifacts"].([]interface{})
-	info.Artifacts = make(map[string]string, 10)
-	for _, artifact := range artifacts {
-		artifactSafe := artifact.(map[string]interface{})
-		displayPath, _ := artifactSafe["displayPath"].(string)
-		relativePath, _ := artifactSafe["relativePath"].(string)
-		if displayPath != "" && relativePath != "" {
-			info.Artifacts[displayPath] = relativePath
-		}
-	}
-	info.Building, _ = json["building"].(bool)
-	info.Duration, _ = json["duration"].(float64)
-	info.EstimatedDuration, _ = json["estimatedDuration"].(float64)
-	if json["result"] != nil {
-		info.Result, _ = json["result"].(string)
-	} else {
-		info.Result = "BUILDING"
+	info.Name = resp.FullDisplayName
+	info.ID = resp.Number
+	info.Artifacts = make(map[string]string, len(resp.Artifacts))
+	for _, artifact := range resp.Artifacts {
+		if artifact.DisplayPath != "" && artifact.RelativePath != "" {
+			info.Artifacts[artifact.DisplayPath] = artifact.RelativePath
+		}
 	}
-	info.Timestamp, _ = json["timestamp"].(float64)
-	info.Url, _ = json["url"].(string)
-	return &info, nil
+	info.Building = resp.Building
+	info.Duration = resp.Duration
+	info.EstimatedDuration = resp.EstimatedDuration
+	info.Result = ParseBuildResult(resp.Result, resp.Building)
+	info.Timestamp = resp.Timestamp
+	info.Url = resp.Url
+	info.BuiltOn = resp.BuiltOn
+	info.Parameters = parseBuildParameters(resp.Actions)
+	info.Causes = parseBuildCauses(resp.Actions)
+	info.Changes = parseChanges(resp.ChangeSet, resp.ChangeSets)
+	info.Git = parseGitInfo(resp.Actions)
+	info.KeepLog = resp.KeepLog
+	info.Description = resp.Description
+	info.PreviousBuild, _ = parsePermalink(resp.PreviousBuild)
+	info.NextBuild, _ = parsePermalink(resp.NextBuild)
+	return &info
+}
+
+func (self *Client) GetInfo(name string) (*JenkinsInfo, error) {
+	return self.GetInfoContext(context.Background(), name)
 }
 
-func GetInfo(name string) (*JenkinsInfo, error) {
-	json, err := get(name, -1)
-	if err != nil || json == nil {
+func (self *Client) GetInfoContext(ctx context.Context, name string) (*JenkinsInfo, error) {
+	resp := jobResponse{}
+	if err := self.getChecked(ctx, name, "", infoTree, infoFields, &resp); err != nil {
 		return nil, err
 	}
+	return parseInfo(&resp), nil
+}
+
+func parseInfo(resp *jobResponse) *JenkinsInfo {
 	info := JenkinsInfo{}
-	info.Name, _ = json["name"].(string)
-	info.Description, _ = json["description"].(string)
-	info.Url, _ = json["url"].(string)
-	info.Buildable, _ = json["buildable"].(bool)
-	info.InQueue, _ = json["inQueue"].(bool)
-	lastBuild := json["lastBuild"]
-	if lastBuild != nil {
-		lastBuildSafe, _ := lastBuild.(map[string]interface{})
-		numF64, _ := lastBuildSafe["number"].(float64)
-		info.LastBuild = int(numF64)
-		info.LastBuildUrl, _ = lastBuildSafe["url"].(string)
-	}
-	lastStableBuild := json["lastStableBuild"]
-	if lastStableBuild != nil {
-		lastStableBuildSafe, _ := lastStableBuild.(map[string]interface{})
-		numF64, _ := lastStableBuildSafe["number"].(float64)
-		info.LastStableBuild = int(numF64)
-		info.LastStableBuildUrl, _ = lastStableBuildSafe["url"].(string)
-	}
-	return &info, nil
+	info.Name = resp.Name
+	info.FullName = resp.FullName
+	info.Description = resp.Description
+	info.Url = resp.Url
+	info.Buildable = resp.Buildable != nil && *resp.Buildable
+	info.InQueue = resp.InQueue
+	if resp.LastBuild != nil {
+		info.LastBuild = resp.LastBuild.Number
+		info.LastBuildUrl = resp.LastBuild.Url
+		info.LastBuildTimestamp = resp.LastBuild.Timestamp
+	}
+	info.LastStableBuild, info.LastStableBuildUrl = parsePermalink(resp.LastStableBuild)
+	info.LastSuccessfulBuild, info.LastSuccessfulBuildUrl = parsePermalink(resp.LastSuccessfulBuild)
+	info.LastFailedBuild, info.LastFailedBuildUrl = parsePermalink(resp.LastFailedBuild)
+	if resp.QueueItem != nil {
+		info.QueueID = resp.QueueItem.ID
+		info.QueueStuck = resp.QueueItem.Stuck
+	}
+	info.Color = strings.TrimSuffix(resp.Color, "_anime")
+	info.Building = strings.HasSuffix(resp.Color, "_anime")
+	if len(resp.HealthReport) > 0 {
+		info.HealthScore = resp.HealthReport[0].Score
+		info.HealthDescription = resp.HealthReport[0].Description
+	}
+	return &info
+}
+
+func parsePermalink(build *buildRef) (int, string) {
+	if build == nil {
+		return 0, ""
+	}
+	return build.Number, build.Url
 }
@@ -0,0 +1,271 @@
+package jenkins
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// newTestClient points a client at handler, with polls that do not sleep and
+// a wait bounded well within the test timeout
+func newTestClient(t *testing.T, handler http.Handler) *Client {
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+	client := NewClient(server.URL)
+	client.Sleep = func(ctx context.Context, d time.Duration) error {
+		return ctx.Err()
+	}
+	client.WaitTimeout = 10 * time.Second
+	return client
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v)
+}
+
+func jsonHandler(v interface{}) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, v)
+	}
+}
+
+// queueHandler answers a trigger with a redirect to the queue item at location
+func queueHandler(location string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "POST" {
+			http.Error(w, "POST required", http.StatusMethodNotAllowed)
+			return
+		}
+		w.Header().Set("Location", location)
+		w.WriteHeader(http.StatusCreated)
+	}
+}
+
+func finishedBuild(number int, result string) map[string]interface{} {
+	return map[string]interface{}{
+		"fullDisplayName": "j #" + strconv.Itoa(number),
+		"number":          number,
+		"building":        false,
+		"result":          result,
+		"artifacts":       []interface{}{},
+	}
+}
+
+func TestDoBuildWithoutStableBuild(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/job/j/api/json", jsonHandler(map[string]interface{}{
+		"name":            "j",
+		"buildable":       true,
+		"lastBuild":       map[string]interface{}{"number": 1},
+		"lastStableBuild": nil,
+	}))
+	mux.HandleFunc("/job/j/build", queueHandler("/queue/item/7/"))
+	mux.HandleFunc("/queue/item/7/api/json", jsonHandler(map[string]interface{}{
+		"executable": map[string]interface{}{"number": 2},
+	}))
+	mux.HandleFunc("/job/j/2/api/json", jsonHandler(finishedBuild(2, "SUCCESS")))
+	client := newTestClient(t, mux)
+
+	binfo, err := client.DoBuild("j", "", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if binfo.ID != 2 || binfo.Result != ResultSuccess {
+		t.Errorf("got build #%d %s, want #2 SUCCESS", binfo.ID, binfo.Result)
+	}
+}
+
+// sequenceHandler serves each document in turn, repeating the last one
+func sequenceHandler(docs ...interface{}) http.HandlerFunc {
+	var lock sync.Mutex
+	served := 0
+	return func(w http.ResponseWriter, r *http.Request) {
+		lock.Lock()
+		doc := docs[served]
+		if served < len(docs)-1 {
+			served++
+		}
+		lock.Unlock()
+		if status, ok := doc.(int); ok {
+			http.Error(w, http.StatusText(status), status)
+			return
+		}
+		writeJSON(w, doc)
+	}
+}
+
+func TestWaitForBuildPollsWhilePending(t *testing.T) {
+	pending := map[string]interface{}{"number": 2, "building": false, "result": nil}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/job/j/api/json", jsonHandler(map[string]interface{}{"name": "j"}))
+	mux.HandleFunc("/job/j/2/api/json", sequenceHandler(pending, pending, finishedBuild(2, "FAILURE")))
+	client := newTestClient(t, mux)
+
+	binfo, err := client.WaitForBuild("j", 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if binfo.Result != ResultFailure {
+		t.Errorf("got %s, want FAILURE", binfo.Result)
+	}
+}
+
+// triggerMux serves a job whose trigger is queued as build 2, leaving the
+// build document to the caller
+func triggerMux(job interface{}) *http.ServeMux {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/job/j/api/json", jsonHandler(job))
+	mux.HandleFunc("/job/j/build", queueHandler("/queue/item/7/"))
+	mux.HandleFunc("/queue/item/7/api/json", jsonHandler(map[string]interface{}{
+		"executable": map[string]interface{}{"number": 2},
+	}))
+	return mux
+}
+
+func TestDoBuildRecoversFromMissingBuild(t *testing.T) {
+	// neither queued nor built yet as far as the job is concerned
+	mux := triggerMux(map[string]interface{}{"name": "j", "lastBuild": map[string]interface{}{"number": 1}})
+	mux.HandleFunc("/job/j/2/api/json", sequenceHandler(http.StatusNotFound, finishedBuild(2, "SUCCESS")))
+	client := newTestClient(t, mux)
+
+	binfo, err := client.DoBuild("j", "", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if binfo.ID != 2 || binfo.Result != ResultSuccess {
+		t.Errorf("got build #%d %s, want #2 SUCCESS", binfo.ID, binfo.Result)
+	}
+}
+
+func TestDoBuildGivesUpOnPersistentlyMissingBuild(t *testing.T) {
+	mux := triggerMux(map[string]interface{}{"name": "j", "lastBuild": map[string]interface{}{"number": 1}})
+	mux.HandleFunc("/job/j/2/api/json", sequenceHandler(http.StatusNotFound))
+	client := newTestClient(t, mux)
+
+	if _, err := client.DoBuild("j", "", true); err == nil || !strings.Contains(err.Error(), "weird state") {
+		t.Errorf("got %v, want the weird state error", err)
+	}
+}
+
+func TestDoBuildContextCancelsWait(t *testing.T) {
+	mux := triggerMux(map[string]interface{}{"name": "j"})
+	mux.HandleFunc("/job/j/2/api/json", jsonHandler(map[string]interface{}{"number": 2, "building": true}))
+	client := newTestClient(t, mux)
+	// sleep for real, so that cancelling has to interrupt the poll interval
+	client.Sleep = nil
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancelled := make(chan time.Time, 1)
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		cancelled <- time.Now()
+		cancel()
+	}()
+	_, err := client.DoBuildContext(ctx, "j", "", true)
+	returned := time.Now()
+	if err != context.Canceled {
+		t.Fatalf("got %v, want context.Canceled", err)
+	}
+	if took := returned.Sub(<-cancelled); took >= 100*time.Millisecond {
+		t.Errorf("returned %v after the context was cancelled", took)
+	}
+}
+
+func TestPostBuild(t *testing.T) {
+	tests := []struct {
+		name   string
+		form   url.Values
+		params bool
+		want   []string
+	}{
+		{"parameterless", nil, false, []string{"/job/j/build"}},
+		{"defaults", nil, true, []string{"/job/j/build", "/job/j/buildWithParameters"}},
+		{"parameters", url.Values{"p": {"1"}}, true, []string{"/job/j/buildWithParameters"}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			hits := []string{}
+			handler := func(w http.ResponseWriter, r *http.Request) {
+				hits = append(hits, r.URL.Path)
+				// Jenkins rejects build for parameterized jobs and
+				// buildWithParameters for the others
+				if test.params == (r.URL.Path == "/job/j/build") {
+					http.Error(w, "wrong endpoint", http.StatusBadRequest)
+					return
+				}
+				queueHandler("/queue/item/7/")(w, r)
+			}
+			mux := http.NewServeMux()
+			mux.HandleFunc("/job/j/build", handler)
+			mux.HandleFunc("/job/j/buildWithParameters", handler)
+			client := newTestClient(t, mux)
+
+			queueURL, err := client.postBuild(context.Background(), "j", test.form, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if want := client.url("queue", "item", "7") + "/"; queueURL != want {
+				t.Errorf("got queue URL %q, want %q", queueURL, want)
+			}
+			if strings.Join(hits, " ") != strings.Join(test.want, " ") {
+				t.Errorf("posted to %v, want %v", hits, test.want)
+			}
+		})
+	}
+}
+
+func TestRequestTimeoutSparesFileUploads(t *testing.T) {
+	slow := func(w http.ResponseWriter, r *http.Request) {
+		io.Copy(io.Discard, r.Body)
+		time.Sleep(200 * time.Millisecond)
+		queueHandler("/queue/item/7/")(w, r)
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/job/j/buildWithParameters", slow)
+	client := newTestClient(t, mux)
+	client.RequestTimeout = 50 * time.Millisecond
+
+	form := url.Values{"p": {"1"}}
+	if _, err := client.postBuild(context.Background(), "j", form, nil); err == nil {
+		t.Error("a slow trigger outlived RequestTimeout")
+	}
+	opts := &BuildOptions{Files: map[string]io.Reader{"upload": strings.NewReader("contents")}}
+	if _, err := client.postBuild(context.Background(), "j", form, opts); err != nil {
+		t.Errorf("a slow upload was cut short: %v", err)
+	}
+}
+
+func TestPostBuildCauseNeedsToken(t *testing.T) {
+	var query url.Values
+	mux := http.NewServeMux()
+	mux.HandleFunc("/job/j/build", func(w http.ResponseWriter, r *http.Request) {
+		query = r.URL.Query()
+		queueHandler("/queue/item/7/")(w, r)
+	})
+	client := newTestClient(t, mux)
+	client.SetCredentials("user", "secret")
+
+	opts := &BuildOptions{Cause: "nightly release"}
+	if _, err := client.postBuild(context.Background(), "j", nil, opts); err == nil {
+		t.Error("sent a cause Jenkins would ignore")
+	}
+	if query != nil {
+		t.Errorf("triggered the build anyway with %v", query)
+	}
+	opts.Token = "trigger"
+	if _, err := client.postBuild(context.Background(), "j", nil, opts); err != nil {
+		t.Fatal(err)
+	}
+	if query.Get("cause") != "nightly release" || query.Get("token") != "trigger" {
+		t.Errorf("got query %v, want the cause and token", query)
+	}
+}
@@ -0,0 +1,290 @@
+package jenkins
+
+import (
+	"context"
+	"encoding/json"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const listJobsTree string = "jobs[name,url,color]"
+
+type JobSummary struct {
+	Name string
+	Url  string
+	// the status ball, e.g. "blue", "red" or "blue_anime" while building
+	Color string
+}
+
+func (self *Client) ListJobs() ([]JobSummary, error) {
+	theurl := self.url("api", "json") + "?tree=" + url.QueryEscape(listJobsTree)
+	json, err := self.getJSON(context.Background(), theurl)
+	if err != nil {
+		return nil, err
+	}
+	jobs, _ := json["jobs"].([]interface{})
+	summaries := make([]JobSummary, 0, len(jobs))
+	for _, job := range jobs {
+		jobSafe, _ := job.(map[string]interface{})
+		summary := JobSummary{}
+		summary.Name, _ = jobSafe["name"].(string)
+		summary.Url, _ = jobSafe["url"].(string)
+		summary.Color, _ = jobSafe["color"].(string)
+		summaries = append(summaries, summary)
+	}
+	return summaries, nil
+}
+
+// JobExists reports whether the job exists; errors other than a clean 404,
+// such as bad credentials, are returned as such.
+func (self *Client) JobExists(name string) (bool, error) {
+	return self.jobExists(context.Background(), name)
+}
+
+func (self *Client) jobExists(ctx context.Context, name string) (bool, error) {
+	_, err := self.getJSON(ctx, self.url(jobPath(name), "api", "json")+"?tree=name")
+	if isNotFound(err) {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+const staleJobsFields string = "name,description,url,buildable,inQueue," +
+	"lastBuild[number,url,timestamp],lastStableBuild[number,url]"
+
+// FindStaleJobs returns the jobs whose last build started more than olderThan
+// ago, including jobs that have never been built and jobs inside folders. It
+// fetches each folder's jobs in a single request.
+func (self *Client) FindStaleJobs(olderThan time.Duration) ([]JenkinsInfo, error) {
+	cutoff := float64(time.Now().Add(-olderThan).UnixNano() / int64(time.Millisecond))
+	stale := []JenkinsInfo{}
+	err := self.walkJobs(context.Background(), "", staleJobsFields, func(fullName string, data json.RawMessage) error {
+		job := jobResponse{}
+		if err := skipTypeErrors(json.Unmarshal(data, &job)); err != nil {
+			return err
+		}
+		if job.Buildable == nil {
+			// views and other items without builds of their own
+			return nil
+		}
+		info := parseInfo(&job)
+		if info.LastBuild == 0 || info.LastBuildTimestamp < cutoff {
+			stale = append(stale, *info)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return stale, nil
+}
+
+// how many jobs GetInfoBatch fetches at once
+const INFO_BATCH_CONCURRENCY = 8
+
+// InfoResult is the outcome of fetching one job's info in GetInfoBatch.
+type InfoResult struct {
+	Info *JenkinsInfo
+	Err  error
+}
+
+// GetInfoBatch fetches the info of every named job, INFO_BATCH_CONCURRENCY at
+// a time and within RequestsPerSecond if set. A failure is recorded against
+// its job without stopping the others.
+func (self *Client) GetInfoBatch(names []string) map[string]InfoResult {
+	results := make(map[string]InfoResult, len(names))
+	var lock sync.Mutex
+	queued := make(chan string)
+	var wg sync.WaitGroup
+	for i := 0; i < INFO_BATCH_CONCURRENCY; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for name := range queued {
+				info, err := self.GetInfo(name)
+				lock.Lock()
+				results[name] = InfoResult{info, err}
+				lock.Unlock()
+			}
+		}()
+	}
+	for _, name := range names {
+		queued <- name
+	}
+	close(queued)
+	wg.Wait()
+	return results
+}
+
+// marks the first line of a job description written by DisableJobWithReason
+const DISABLED_REASON_PREFIX string = "Disabled: "
+
+const disabledJobsFields string = "description,buildable"
+
+// DisableJob stops a job from being built; GetInfo then reports it as not
+// Buildable.
+func (self *Client) DisableJob(name string) error {
+	return self.setJobEnabled(name, false)
+}
+
+func (self *Client) EnableJob(name string) error {
+	return self.setJobEnabled(name, true)
+}
+
+func (self *Client) setJobEnabled(name string, enabled bool) error {
+	action := "disable"
+	if enabled {
+		action = "enable"
+	}
+	return self.postForm(context.Background(), self.url(jobPath(name), action), nil)
+}
+
+func (self *Client) setJobDescription(name, description string) error {
+	form := url.Values{}
+	form.Set("description", description)
+	return self.postForm(context.Background(), self.url(jobPath(name), "submitDescription"), form)
+}
+
+// SetBuildDescription annotates a build, e.g. with the version it released.
+func (self *Client) SetBuildDescription(name string, id int, description string) error {
+	id, err := self.sanitizeID(context.Background(), name, id)
+	if err != nil {
+		return err
+	}
+	form := url.Values{}
+	form.Set("description", description)
+	return self.postForm(context.Background(), self.url(jobPath(name), strconv.Itoa(id), "submitDescription"), form)
+}
+
+// DisableJobWithReason disables a job and records the reason as the first line
+// of its description. The original description is kept below it and restored
+// by EnableJobsDisabledBy.
+func (self *Client) DisableJobWithReason(name, reason string) error {
+	info, err := self.GetInfo(name)
+	if err != nil {
+		return err
+	}
+	if err := self.setJobEnabled(name, false); err != nil {
+		return err
+	}
+	return self.setJobDescription(name, DISABLED_REASON_PREFIX+reason+"\n"+info.Description)
+}
+
+// EnableJobsDisabledBy re-enables every disabled job whose recorded reason
+// starts with reasonPrefix, including jobs inside folders, and returns how
+// many were enabled.
+func (self *Client) EnableJobsDisabledBy(reasonPrefix string) (int, error) {
+	enabled := 0
+	err := self.walkJobs(context.Background(), "", disabledJobsFields, func(fullName string, data json.RawMessage) error {
+		job := jobResponse{}
+		if err := skipTypeErrors(json.Unmarshal(data, &job)); err != nil {
+			return err
+		}
+		if fullName == "" || job.Buildable == nil || *job.Buildable ||
+			!strings.HasPrefix(job.Description, DISABLED_REASON_PREFIX+reasonPrefix) {
+			return nil
+		}
+		if err := self.setJobEnabled(fullName, true); err != nil {
+			return err
+		}
+		original := ""
+		if i := strings.Index(job.Description, "\n"); i >= 0 {
+			original = job.Description[i+1:]
+		}
+		if err := self.setJobDescription(fullName, original); err != nil {
+			return err
+		}
+		enabled++
+		return nil
+	})
+	return enabled, err
+}
+
+// a job as walkJobs lists it
+type listedJob struct {
+	FullName string `json:"fullName"`
+	// only folders have jobs of their own
+	Jobs []struct{} `json:"jobs"`
+}
+
+// walkJobs calls visit with every job under folder, "" for the top level,
+// descending into folders. Each job is listed with fields, which, like those
+// of a tree parameter, name what visit decodes.
+func (self *Client) walkJobs(ctx context.Context, folder, fields string, visit func(fullName string, data json.RawMessage) error) error {
+	tree := "jobs[fullName,jobs[name]," + fields + "]"
+	theurl := self.url(jobPath(folder), "api", "json") + "?tree=" + url.QueryEscape(tree)
+	listing := struct {
+		Jobs []json.RawMessage `json:"jobs"`
+	}{}
+	if err := self.decodeJSON(ctx, theurl, &listing); err != nil {
+		return err
+	}
+	for _, data := range listing.Jobs {
+		job := listedJob{}
+		if err := skipTypeErrors(json.Unmarshal(data, &job)); err != nil {
+			return err
+		}
+		var err error
+		if job.Jobs != nil {
+			err = self.walkJobs(ctx, job.FullName, fields, visit)
+		} else {
+			err = visit(job.FullName, data)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// how many of the most recent builds StopAllBuilds checks for running ones
+const stopScanLimit = 50
+
+// StopBuild aborts a running build. Jenkins answers with a redirect to the
+// build page whether or not it was still running, so stopping a finished build
+// is not an error.
+func (self *Client) StopBuild(name string, id int) error {
+	id, err := self.sanitizeID(context.Background(), name, id)
+	if err != nil {
+		return err
+	}
+	return self.postForm(context.Background(), self.url(jobPath(name), strconv.Itoa(id), "stop"), nil)
+}
+
+// SetBuildKept marks a build to be kept forever, or releases it back to log
+// rotation. Jenkins only offers a toggle, so the current state is read first.
+func (self *Client) SetBuildKept(name string, id int, kept bool) error {
+	info, err := self.GetBuildInfo(name, id)
+	if err != nil {
+		return err
+	}
+	if info.KeepLog == kept {
+		return nil
+	}
+	return self.postForm(context.Background(), self.url(jobPath(name), strconv.Itoa(info.ID), "toggleLogKeep"), nil)
+}
+
+// StopAllBuilds stops every running build among the job's recent builds and
+// returns how many were stopped.
+func (self *Client) StopAllBuilds(name string) (int, error) {
+	builds, err := self.ListBuilds(name, stopScanLimit)
+	if err != nil {
+		return 0, err
+	}
+	stopped := 0
+	for _, build := range builds {
+		if !build.Building {
+			continue
+		}
+		if err := self.StopBuild(name, build.Number); err != nil {
+			return stopped, err
+		}
+		stopped++
+	}
+	return stopped, nil
+}
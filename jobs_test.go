@@ -0,0 +1,100 @@
+package jenkins
+
+import (
+	"net/http"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestEnableJobsDisabledByInFolders(t *testing.T) {
+	disabled := func(fullName, description string) map[string]interface{} {
+		return map[string]interface{}{"fullName": fullName, "buildable": false, "description": description}
+	}
+	frozen := DISABLED_REASON_PREFIX + "release freeze\nThe service."
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/json", jsonHandler(map[string]interface{}{
+		"jobs": []interface{}{
+			disabled("top", frozen),
+			map[string]interface{}{"fullName": "team", "jobs": []interface{}{}},
+		},
+	}))
+	mux.HandleFunc("/job/team/api/json", jsonHandler(map[string]interface{}{
+		"jobs": []interface{}{
+			disabled("team/svc", frozen),
+			disabled("team/other", DISABLED_REASON_PREFIX+"broken"),
+			map[string]interface{}{"fullName": "team/live", "buildable": true, "description": frozen},
+		},
+	}))
+	var lock sync.Mutex
+	posts := []string{}
+	descriptions := map[string]string{}
+	mux.HandleFunc("/job/", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "POST" {
+			http.NotFound(w, r)
+			return
+		}
+		lock.Lock()
+		defer lock.Unlock()
+		posts = append(posts, r.URL.Path)
+		if strings.HasSuffix(r.URL.Path, "/submitDescription") {
+			descriptions[r.URL.Path] = r.FormValue("description")
+		}
+	})
+	client := newTestClient(t, mux)
+
+	enabled, err := client.EnableJobsDisabledBy("release")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if enabled != 2 {
+		t.Errorf("enabled %d jobs, want 2", enabled)
+	}
+	sort.Strings(posts)
+	want := []string{
+		"/job/team/job/svc/enable",
+		"/job/team/job/svc/submitDescription",
+		"/job/top/enable",
+		"/job/top/submitDescription",
+	}
+	if strings.Join(posts, " ") != strings.Join(want, " ") {
+		t.Errorf("posted to %v, want %v", posts, want)
+	}
+	if got := descriptions["/job/team/job/svc/submitDescription"]; got != "The service." {
+		t.Errorf("restored description %q, want the original", got)
+	}
+}
+
+func TestFindStaleJobsInFolders(t *testing.T) {
+	recent := float64(time.Now().UnixNano() / int64(time.Millisecond))
+	job := func(fullName string, timestamp float64) map[string]interface{} {
+		return map[string]interface{}{
+			"name":      path.Base(fullName),
+			"fullName":  fullName,
+			"buildable": true,
+			"lastBuild": map[string]interface{}{"number": 3, "timestamp": timestamp},
+		}
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/json", jsonHandler(map[string]interface{}{
+		"jobs": []interface{}{
+			job("fresh", recent),
+			map[string]interface{}{"fullName": "team", "jobs": []interface{}{}},
+		},
+	}))
+	mux.HandleFunc("/job/team/api/json", jsonHandler(map[string]interface{}{
+		"jobs": []interface{}{job("team/old", 1000), job("team/fresh", recent)},
+	}))
+	client := newTestClient(t, mux)
+
+	stale, err := client.FindStaleJobs(time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(stale) != 1 || stale[0].FullName != "team/old" {
+		t.Errorf("got %+v, want just team/old", stale)
+	}
+}
@@ -0,0 +1,121 @@
+package jenkins
+
+import (
+	"context"
+	"log"
+	"net/url"
+)
+
+// the built-in node reports an empty builtOn and lives at /computer/(master)
+const MASTER_NODE string = "(master)"
+
+type NodeInfo struct {
+	Name               string
+	Description        string
+	Labels             []string
+	Offline            bool
+	TemporarilyOffline bool
+	OfflineReason      string
+}
+
+func (self *NodeInfo) Print() {
+	log.Println("Node Info For", self.Name)
+	log.Println("  description        :", self.Description)
+	log.Println("  labels             :", self.Labels)
+	log.Println("  offline            :", self.Offline)
+	log.Println("  temporarilyOffline :", self.TemporarilyOffline)
+	log.Println("  offlineReason      :", self.OfflineReason)
+}
+
+func (self *Client) GetNodeInfo(node string) (*NodeInfo, error) {
+	if node == "" {
+		node = MASTER_NODE
+	}
+	json, err := self.getJSON(context.Background(), self.url("computer", node, "api", "json"))
+	if err != nil || json == nil {
+		return nil, err
+	}
+	info := NodeInfo{}
+	info.Name, _ = json["displayName"].(string)
+	info.Description, _ = json["description"].(string)
+	info.Offline, _ = json["offline"].(bool)
+	info.TemporarilyOffline, _ = json["temporarilyOffline"].(bool)
+	info.OfflineReason, _ = json["offlineCauseReason"].(string)
+	labels, _ := json["assignedLabels"].([]interface{})
+	info.Labels = make([]string, 0, len(labels))
+	for _, label := range labels {
+		labelSafe, _ := label.(map[string]interface{})
+		labelName, _ := labelSafe["name"].(string)
+		if labelName != "" {
+			info.Labels = append(info.Labels, labelName)
+		}
+	}
+	return &info, nil
+}
+
+func (self *Client) GetBuildNode(name string, id int) (*NodeInfo, error) {
+	info, err := self.GetBuildInfo(name, id)
+	if err != nil {
+		return nil, err
+	}
+	return self.GetNodeInfo(info.BuiltOn)
+}
+
+const nodesTree string = "busyExecutors,totalExecutors," +
+	"computer[displayName,offline,temporarilyOffline,numExecutors,executors[idle]]"
+
+type nodesResponse struct {
+	BusyExecutors  int `json:"busyExecutors"`
+	TotalExecutors int `json:"totalExecutors"`
+	Computer       []struct {
+		DisplayName        string `json:"displayName"`
+		Offline            bool   `json:"offline"`
+		TemporarilyOffline bool   `json:"temporarilyOffline"`
+		NumExecutors       int    `json:"numExecutors"`
+		Executors          []struct {
+			Idle bool `json:"idle"`
+		} `json:"executors"`
+	} `json:"computer"`
+}
+
+type NodeStatus struct {
+	Name               string
+	Offline            bool
+	TemporarilyOffline bool
+	Executors          int
+	IdleExecutors      int
+}
+
+// Nodes is the executor capacity of the whole server.
+type Nodes struct {
+	BusyExecutors  int
+	TotalExecutors int
+	Nodes          []NodeStatus
+}
+
+// GetNodes lists every node with its executors, e.g. to explain a build
+// waiting in the queue because no executor is free.
+func (self *Client) GetNodes() (*Nodes, error) {
+	theurl := self.url("computer", "api", "json") + "?tree=" + url.QueryEscape(nodesTree)
+	resp := nodesResponse{}
+	if err := skipTypeErrors(self.decodeJSON(context.Background(), theurl, &resp)); err != nil {
+		return nil, err
+	}
+	nodes := Nodes{BusyExecutors: resp.BusyExecutors, TotalExecutors: resp.TotalExecutors}
+	nodes.Nodes = make([]NodeStatus, 0, len(resp.Computer))
+	for _, computer := range resp.Computer {
+		node := NodeStatus{
+			Name:               computer.DisplayName,
+			Offline:            computer.Offline,
+			TemporarilyOffline: computer.TemporarilyOffline,
+			Executors:          computer.NumExecutors,
+		}
+		for _, executor := range computer.Executors {
+			if executor.Idle {
+				node.IdleExecutors++
+			}
+		}
+		nodes.Nodes = append(nodes.Nodes, node)
+	}
+	return &nodes, nil
+}
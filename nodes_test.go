@@ -0,0 +1,49 @@
+package jenkins
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestGetNodes(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/computer/api/json", jsonHandler(map[string]interface{}{
+		"busyExecutors":  1,
+		"totalExecutors": 3,
+		"computer": []interface{}{
+			map[string]interface{}{
+				"displayName":  "master",
+				"numExecutors": 2,
+				"executors":    []interface{}{map[string]interface{}{"idle": true}, map[string]interface{}{"idle": false}},
+			},
+			map[string]interface{}{
+				"displayName":        "agent",
+				"offline":            true,
+				"temporarilyOffline": true,
+				"numExecutors":       1,
+				"executors":          []interface{}{map[string]interface{}{"idle": true}},
+			},
+		},
+	}))
+	client := newTestClient(t, mux)
+
+	nodes, err := client.GetNodes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if nodes.BusyExecutors != 1 || nodes.TotalExecutors != 3 {
+		t.Errorf("got %d of %d executors busy, want 1 of 3", nodes.BusyExecutors, nodes.TotalExecutors)
+	}
+	want := []NodeStatus{
+		{Name: "master", Executors: 2, IdleExecutors: 1},
+		{Name: "agent", Offline: true, TemporarilyOffline: true, Executors: 1, IdleExecutors: 1},
+	}
+	if len(nodes.Nodes) != len(want) {
+		t.Fatalf("got %d nodes, want %d", len(nodes.Nodes), len(want))
+	}
+	for i := range want {
+		if nodes.Nodes[i] != want[i] {
+			t.Errorf("node %d: got %+v, want %+v", i, nodes.Nodes[i], want[i])
+		}
+	}
+}
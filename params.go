@@ -0,0 +1,224 @@
+package jenkins
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// replaces the value of secret parameters wherever they would be shown
+const REDACTED string = "********"
+
+const parametersTree string = "property[parameterDefinitions[name,type,description,choices,defaultParameterValue[value]]]"
+
+type ParameterDefinition struct {
+	Name string
+	// the definition's class, e.g. StringParameterDefinition,
+	// BooleanParameterDefinition, ChoiceParameterDefinition or
+	// PasswordParameterDefinition
+	Type        string
+	Description string
+	Default     string
+	HasDefault  bool
+	// password parameters; their default is never returned
+	Secret bool
+	// the allowed values of a choice parameter
+	Choices []string
+
+	// a secret's redacted Default hides whether it was empty
+	emptyDefault bool
+}
+
+// Required reports whether a build would run with an empty value for this
+// parameter if the caller did not provide one.
+func (self *ParameterDefinition) Required() bool {
+	if self.Type == "BooleanParameterDefinition" {
+		return false
+	}
+	return !self.HasDefault || self.Default == "" || self.emptyDefault
+}
+
+// GetJobParameters returns the parameters the job accepts, in the order
+// Jenkins shows them, e.g. to prompt for each before triggering a build.
+func (self *Client) GetJobParameters(name string) ([]ParameterDefinition, error) {
+	theurl := self.url(jobPath(name), "api", "json") + "?tree=" + url.QueryEscape(parametersTree)
+	json, err := self.getJSON(context.Background(), theurl)
+	if err != nil {
+		return nil, err
+	}
+	params := []ParameterDefinition{}
+	properties, _ := json["property"].([]interface{})
+	for _, property := range properties {
+		propertySafe, _ := property.(map[string]interface{})
+		definitions, _ := propertySafe["parameterDefinitions"].([]interface{})
+		for _, definition := range definitions {
+			definitionSafe, _ := definition.(map[string]interface{})
+			param := ParameterDefinition{}
+			param.Name, _ = definitionSafe["name"].(string)
+			param.Type, _ = definitionSafe["type"].(string)
+			param.Description, _ = definitionSafe["description"].(string)
+			defaultValue, _ := definitionSafe["defaultParameterValue"].(map[string]interface{})
+			if defaultValue != nil {
+				param.Default, param.HasDefault = formatParamValue(defaultValue["value"])
+			}
+			choices, _ := definitionSafe["choices"].([]interface{})
+			for _, choice := range choices {
+				if choiceStr, ok := choice.(string); ok {
+					param.Choices = append(param.Choices, choiceStr)
+				}
+			}
+			if param.Type == "PasswordParameterDefinition" {
+				param.Secret = true
+				if param.HasDefault {
+					param.emptyDefault = param.Default == ""
+					param.Default = REDACTED
+				}
+			}
+			params = append(params, param)
+		}
+	}
+	return params, nil
+}
+
+func formatParamValue(value interface{}) (string, bool) {
+	switch v := value.(type) {
+	case string:
+		return v, true
+	case bool:
+		return strconv.FormatBool(v), true
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), true
+	}
+	return "", false
+}
+
+func paramsForm(params map[string]string) url.Values {
+	form := url.Values{}
+	for key, value := range params {
+		form.Set(key, value)
+	}
+	return form
+}
+
+// MissingRequiredParams returns the names of required parameters of the job
+// that are absent or empty in provided.
+func (self *Client) MissingRequiredParams(name string, provided map[string]string) ([]string, error) {
+	params, err := self.GetJobParameters(name)
+	if err != nil {
+		return nil, err
+	}
+	missing := []string{}
+	for _, param := range params {
+		if param.Required() && provided[param.Name] == "" {
+			missing = append(missing, param.Name)
+		}
+	}
+	return missing, nil
+}
+
+// parseParams turns a query string as passed to DoBuild into a map
+func parseParams(params string) (map[string]string, error) {
+	form, err := url.ParseQuery(params)
+	if err != nil {
+		// the parse error quotes the offending value, which may be a secret
+		return nil, errors.New("invalid build parameters")
+	}
+	provided := make(map[string]string, len(form))
+	for key := range form {
+		provided[key] = form.Get(key)
+	}
+	return provided, nil
+}
+
+// ValidateParams checks provided against the job's parameter definitions:
+// every name must be defined, booleans must be "true" or "false" and choices
+// one of the allowed values.
+func (self *Client) ValidateParams(name string, provided map[string]string) error {
+	definitions, err := self.GetJobParameters(name)
+	if err != nil {
+		return err
+	}
+	byName := make(map[string]ParameterDefinition, len(definitions))
+	for _, definition := range definitions {
+		byName[definition.Name] = definition
+	}
+	problems := []string{}
+	for key, value := range provided {
+		definition, ok := byName[key]
+		if !ok {
+			problems = append(problems, "unknown parameter "+key)
+		} else if definition.Type == "BooleanParameterDefinition" && value != "true" && value != "false" {
+			problems = append(problems, key+" must be true or false")
+		} else if definition.Type == "ChoiceParameterDefinition" && !containsString(definition.Choices, value) {
+			problems = append(problems, key+" must be one of "+strings.Join(definition.Choices, ", "))
+		}
+	}
+	if len(problems) > 0 {
+		sort.Strings(problems)
+		return errors.New("invalid parameters for " + name + ": " + strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+func (self *Client) checkRequiredParams(name, params string) error {
+	provided, err := parseParams(params)
+	if err != nil {
+		return err
+	}
+	missing, err := self.MissingRequiredParams(name, provided)
+	if err != nil {
+		return err
+	}
+	if len(missing) > 0 {
+		return errors.New("missing required parameters for " + name + ": " + strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+// RedactParams returns params, a query string as passed to DoBuild, with the
+// values of the job's secret parameters replaced so it is safe to log.
+func (self *Client) RedactParams(name, params string) (string, error) {
+	form, err := url.ParseQuery(params)
+	if err != nil {
+		return "", errors.New("invalid build parameters")
+	}
+	definitions, err := self.GetJobParameters(name)
+	if err != nil {
+		return "", err
+	}
+	for _, definition := range definitions {
+		if _, ok := form[definition.Name]; ok && definition.Secret {
+			form.Set(definition.Name, REDACTED)
+		}
+	}
+	return form.Encode(), nil
+}
+
+func parseBuildParameters(actions []actionResponse) map[string]string {
+	params := map[string]string{}
+	for _, action := range actions {
+		for _, value := range action.Parameters {
+			if value.Name == "" {
+				continue
+			}
+			if strings.HasSuffix(value.Class, "PasswordParameterValue") {
+				params[value.Name] = REDACTED
+			} else {
+				params[value.Name], _ = formatParamValue(value.Value)
+			}
+		}
+	}
+	return params
+}
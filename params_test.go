@@ -0,0 +1,47 @@
+package jenkins
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func parameterDefinition(class, defaultValue string) map[string]interface{} {
+	return map[string]interface{}{
+		"name":                  strings.ToLower(class) + "-" + defaultValue,
+		"type":                  class,
+		"defaultParameterValue": map[string]interface{}{"value": defaultValue},
+	}
+}
+
+func TestMissingRequiredParams(t *testing.T) {
+	definitions := []interface{}{
+		parameterDefinition("StringParameterDefinition", ""),
+		parameterDefinition("StringParameterDefinition", "set"),
+		parameterDefinition("PasswordParameterDefinition", ""),
+		parameterDefinition("PasswordParameterDefinition", "set"),
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/job/j/api/json", jsonHandler(map[string]interface{}{
+		"property": []interface{}{map[string]interface{}{"parameterDefinitions": definitions}},
+	}))
+	client := newTestClient(t, mux)
+
+	missing, err := client.MissingRequiredParams("j", map[string]string{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "stringparameterdefinition- passwordparameterdefinition-"
+	if strings.Join(missing, " ") != want {
+		t.Errorf("got missing %v, want %s", missing, want)
+	}
+	params, err := client.GetJobParameters("j")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, param := range params {
+		if param.Secret && param.Default != REDACTED {
+			t.Errorf("%s: default %q is not redacted", param.Name, param.Default)
+		}
+	}
+}
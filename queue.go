@@ -0,0 +1,186 @@
+package jenkins
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// waitForQueuedBuild polls a queue item until Jenkins assigns it a build
+// number.
+func (self *Client) waitForQueuedBuild(ctx context.Context, queueURL string) (int, error) {
+	if queueURL == "" {
+		return 0, errors.New("Jenkins did not report a queue item for the build")
+	}
+	if !strings.HasSuffix(queueURL, "/") {
+		queueURL += "/"
+	}
+	backoff := self.pollBackoff()
+	for {
+		json, err := self.getJSON(ctx, queueURL+"api/json")
+		if err != nil {
+			return 0, err
+		}
+		if cancelled, _ := json["cancelled"].(bool); cancelled {
+			return 0, errors.New("queue item was cancelled: " + queueURL)
+		}
+		executable, _ := json["executable"].(map[string]interface{})
+		if numF64, ok := executable["number"].(float64); ok {
+			return int(numF64), nil
+		}
+		if err := self.sleep(ctx, backoff.Next()); err != nil {
+			return 0, err
+		}
+	}
+}
+
+const queueTree string = "items[id,why,inQueueSince,stuck,task[name,url]]"
+
+type queueResponse struct {
+	Items []struct {
+		ID           int     `json:"id"`
+		Why          string  `json:"why"`
+		InQueueSince float64 `json:"inQueueSince"`
+		Stuck        bool    `json:"stuck"`
+		Task         struct {
+			Name string `json:"name"`
+			Url  string `json:"url"`
+		} `json:"task"`
+	} `json:"items"`
+}
+
+type QueueItem struct {
+	ID int
+	// the job the item will build
+	Name string
+	Url  string
+	// why it is still waiting, e.g. "Waiting for next available executor"
+	Why string
+	// milliseconds since the epoch
+	InQueueSince float64
+	Stuck        bool
+}
+
+// GetQueue lists the builds waiting to run.
+func (self *Client) GetQueue() ([]QueueItem, error) {
+	theurl := self.url("queue", "api", "json") + "?tree=" + url.QueryEscape(queueTree)
+	resp := queueResponse{}
+	if err := skipTypeErrors(self.decodeJSON(context.Background(), theurl, &resp)); err != nil {
+		return nil, err
+	}
+	queue := make([]QueueItem, 0, len(resp.Items))
+	for _, item := range resp.Items {
+		queue = append(queue, QueueItem{
+			ID:           item.ID,
+			Name:         item.Task.Name,
+			Url:          item.Task.Url,
+			Why:          item.Why,
+			InQueueSince: item.InQueueSince,
+			Stuck:        item.Stuck,
+		})
+	}
+	return queue, nil
+}
+
+// CancelQueueItem removes a build from the queue before it starts; a build
+// that is already running has to be stopped with StopBuild instead.
+func (self *Client) CancelQueueItem(queueID int) error {
+	theurl := self.url("queue", "cancelItem") + "?id=" + strconv.Itoa(queueID)
+	err := self.postForm(context.Background(), theurl, nil)
+	if isNotFound(err) {
+		return errors.New("queue item " + strconv.Itoa(queueID) + " is no longer in the queue")
+	}
+	return err
+}
+
+// DoBuildAndStream triggers a build, streams its console log to w while it
+// runs and returns the final build info. Like DoBuild, the wait is bounded by
+// WaitTimeout.
+func (self *Client) DoBuildAndStream(name string, params map[string]string, w io.Writer) (*JenkinsBuildInfo, error) {
+	ctx := context.Background()
+	queueURL, err := self.postBuild(ctx, name, paramsForm(params), nil)
+	if err != nil {
+		return nil, err
+	}
+	return self.streamBuild(ctx, name, queueURL, w)
+}
+
+// BuildAndStream is DoBuildAndStream with the parameters given as a query
+// string, like DoBuild.
+func (self *Client) BuildAndStream(name, params string, w io.Writer) (*JenkinsBuildInfo, error) {
+	ctx := context.Background()
+	queueURL, err := self.post(ctx, name, params, nil)
+	if err != nil {
+		return nil, err
+	}
+	return self.streamBuild(ctx, name, queueURL, w)
+}
+
+func (self *Client) streamBuild(ctx context.Context, name, queueURL string, w io.Writer) (*JenkinsBuildInfo, error) {
+	info, err := self.GetInfoContext(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	lastStableMillis, err := self.lastStableDuration(ctx, name, info)
+	if err != nil {
+		return nil, err
+	}
+	waitCtx, timeout, cancel := self.waitContext(ctx, lastStableMillis)
+	defer cancel()
+	state := "queued"
+	id, err := self.waitForQueuedBuild(waitCtx, queueURL)
+	if err != nil {
+		return nil, waitError(ctx, waitCtx, timeout, name, state, err)
+	}
+	state = "build #" + strconv.Itoa(id) + " building"
+	console, err := self.tailConsoleLog(waitCtx, name, id)
+	if err != nil {
+		return nil, waitError(ctx, waitCtx, timeout, name, state, err)
+	}
+	_, err = io.Copy(w, console)
+	console.Close()
+	if err != nil {
+		return nil, waitError(ctx, waitCtx, timeout, name, state, err)
+	}
+	for {
+		// the log can finish a moment before the build is marked complete
+		binfo, err := self.GetBuildInfoContext(waitCtx, name, id)
+		if err != nil {
+			return nil, waitError(ctx, waitCtx, timeout, name, state, err)
+		}
+		if binfo.finished() {
+			return binfo, nil
+		}
+		if err := self.sleep(waitCtx, self.pollInterval()); err != nil {
+			return nil, waitError(ctx, waitCtx, timeout, name, state, err)
+		}
+	}
+}
+
+// a running build is considered stuck once it has taken this many times its
+// estimated duration
+var STUCK_DURATION_FACTOR float64 = 3
+
+// IsBuildStuck reports whether a build is running far beyond its estimate, or,
+// if it has not started yet, whether Jenkins flags its queue item as stuck.
+func (self *Client) IsBuildStuck(name string, id int) (bool, error) {
+	binfo, err := self.GetBuildInfo(name, id)
+	if isNotFound(err) {
+		info, err := self.GetInfo(name)
+		if err != nil {
+			return false, err
+		}
+		return info.InQueue && info.QueueStuck, nil
+	} else if err != nil {
+		return false, err
+	}
+	if !binfo.Building || binfo.EstimatedDuration <= 0 {
+		return false, nil
+	}
+	elapsed := float64(time.Now().UnixNano()/int64(time.Millisecond)) - binfo.Timestamp
+	return elapsed > STUCK_DURATION_FACTOR*binfo.EstimatedDuration, nil
+}
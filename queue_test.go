@@ -0,0 +1,132 @@
+package jenkins
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestDoBuildWaitsOnItsOwnBuild(t *testing.T) {
+	// the triggers are numbered in the opposite order, and neither is the
+	// job's last build plus one
+	queued := map[string]string{"a": "/queue/item/10/", "b": "/queue/item/11/"}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/job/j/api/json", jsonHandler(map[string]interface{}{
+		"name":      "j",
+		"lastBuild": map[string]interface{}{"number": 5},
+	}))
+	mux.HandleFunc("/job/j/buildWithParameters", func(w http.ResponseWriter, r *http.Request) {
+		queueHandler(queued[r.FormValue("who")])(w, r)
+	})
+	mux.HandleFunc("/queue/item/10/api/json", jsonHandler(map[string]interface{}{
+		"executable": map[string]interface{}{"number": 7},
+	}))
+	mux.HandleFunc("/queue/item/11/api/json", jsonHandler(map[string]interface{}{
+		"executable": map[string]interface{}{"number": 6},
+	}))
+	mux.HandleFunc("/job/j/6/api/json", jsonHandler(finishedBuild(6, "FAILURE")))
+	mux.HandleFunc("/job/j/7/api/json", jsonHandler(finishedBuild(7, "SUCCESS")))
+	client := newTestClient(t, mux)
+
+	var wg sync.WaitGroup
+	results := map[string]*JenkinsBuildInfo{}
+	var lock sync.Mutex
+	for _, who := range []string{"a", "b"} {
+		wg.Add(1)
+		go func(who string) {
+			defer wg.Done()
+			binfo, err := client.DoBuild("j", "who="+who, true)
+			if err != nil {
+				t.Error(who, err)
+				return
+			}
+			lock.Lock()
+			results[who] = binfo
+			lock.Unlock()
+		}(who)
+	}
+	wg.Wait()
+	if binfo := results["a"]; binfo == nil || binfo.ID != 7 || binfo.Result != ResultSuccess {
+		t.Errorf("a got %+v, want #7 SUCCESS", binfo)
+	}
+	if binfo := results["b"]; binfo == nil || binfo.ID != 6 || binfo.Result != ResultFailure {
+		t.Errorf("b got %+v, want #6 FAILURE", binfo)
+	}
+}
+
+func TestBuildAndStream(t *testing.T) {
+	pending := map[string]interface{}{"number": 2, "building": false, "result": nil}
+	mux := triggerMux(map[string]interface{}{"name": "j"})
+	mux.HandleFunc("/job/j/2/logText/progressiveText", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Text-Size", "6")
+		io.WriteString(w, "hello\n")
+	})
+	// the log is complete before the build is
+	mux.HandleFunc("/job/j/2/api/json", sequenceHandler(pending, finishedBuild(2, "SUCCESS")))
+	client := newTestClient(t, mux)
+
+	var out strings.Builder
+	binfo, err := client.BuildAndStream("j", "", &out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if binfo.ID != 2 || binfo.Result != ResultSuccess {
+		t.Errorf("got build #%d %s, want #2 SUCCESS", binfo.ID, binfo.Result)
+	}
+	if out.String() != "hello\n" {
+		t.Errorf("streamed %q, want the console log", out.String())
+	}
+}
+
+func TestBuildAndStreamTimesOutInQueue(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/job/j/api/json", jsonHandler(map[string]interface{}{"name": "j"}))
+	mux.HandleFunc("/job/j/build", queueHandler("/queue/item/7/"))
+	mux.HandleFunc("/queue/item/7/api/json", jsonHandler(map[string]interface{}{"why": "Waiting for next available executor"}))
+	client := newTestClient(t, mux)
+	client.Sleep = nil
+	client.PollInterval = 10 * time.Millisecond
+	client.WaitTimeout = 100 * time.Millisecond
+
+	_, err := client.BuildAndStream("j", "", io.Discard)
+	if err == nil || !strings.Contains(err.Error(), "timed out") {
+		t.Errorf("got %v, want a timeout", err)
+	}
+}
+
+func TestGetQueue(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/queue/api/json", jsonHandler(map[string]interface{}{
+		"items": []interface{}{
+			map[string]interface{}{
+				"id":           7,
+				"why":          "Waiting for next available executor",
+				"inQueueSince": 1000,
+				"stuck":        true,
+				"task":         map[string]interface{}{"name": "j", "url": "http://ci/job/j/"},
+			},
+			map[string]interface{}{"id": 8, "task": map[string]interface{}{"name": "k"}},
+		},
+	}))
+	client := newTestClient(t, mux)
+
+	queue, err := client.GetQueue()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []QueueItem{
+		{ID: 7, Name: "j", Url: "http://ci/job/j/", Why: "Waiting for next available executor", InQueueSince: 1000, Stuck: true},
+		{ID: 8, Name: "k"},
+	}
+	if len(queue) != len(want) {
+		t.Fatalf("got %d items, want %d", len(queue), len(want))
+	}
+	for i := range want {
+		if queue[i] != want[i] {
+			t.Errorf("item %d: got %+v, want %+v", i, queue[i], want[i])
+		}
+	}
+}
@@ -0,0 +1,37 @@
+package jenkins
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// rateLimiter spaces requests at least a fixed interval apart
+type rateLimiter struct {
+	lock sync.Mutex
+	next time.Time
+}
+
+// wait blocks until a request may be sent, reserving the slot after it
+func (self *rateLimiter) wait(ctx context.Context, client *Client, interval time.Duration) error {
+	self.lock.Lock()
+	now := time.Now()
+	if self.next.Before(now) {
+		self.next = now
+	}
+	delay := self.next.Sub(now)
+	self.next = self.next.Add(interval)
+	self.lock.Unlock()
+	if delay <= 0 {
+		return nil
+	}
+	return client.sleep(ctx, delay)
+}
+
+func (self *Client) waitForRateLimit(ctx context.Context) error {
+	if self.RequestsPerSecond <= 0 {
+		return nil
+	}
+	interval := time.Duration(float64(time.Second) / self.RequestsPerSecond)
+	return self.limiter.wait(ctx, self, interval)
+}
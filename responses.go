@@ -0,0 +1,145 @@
+package jenkins
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/url"
+	"path"
+)
+
+// the parts of the api/json documents GetInfo and GetBuildInfo read
+
+type buildRef struct {
+	Number    int     `json:"number"`
+	Url       string  `json:"url"`
+	Timestamp float64 `json:"timestamp"`
+}
+
+type jobResponse struct {
+	Name        string `json:"name"`
+	FullName    string `json:"fullName"`
+	Description string `json:"description"`
+	Url         string `json:"url"`
+	// nil for folders and views, which have no builds of their own
+	Buildable           *bool     `json:"buildable"`
+	InQueue             bool      `json:"inQueue"`
+	LastBuild           *buildRef `json:"lastBuild"`
+	LastStableBuild     *buildRef `json:"lastStableBuild"`
+	LastSuccessfulBuild *buildRef `json:"lastSuccessfulBuild"`
+	LastFailedBuild     *buildRef `json:"lastFailedBuild"`
+	QueueItem           *struct {
+		ID    int  `json:"id"`
+		Stuck bool `json:"stuck"`
+	} `json:"queueItem"`
+	Color        string `json:"color"`
+	HealthReport []struct {
+		Score       int    `json:"score"`
+		Description string `json:"description"`
+	} `json:"healthReport"`
+}
+
+type buildResponse struct {
+	FullDisplayName string `json:"fullDisplayName"`
+	Number          int    `json:"number"`
+	Artifacts       []struct {
+		DisplayPath  string `json:"displayPath"`
+		RelativePath string `json:"relativePath"`
+	} `json:"artifacts"`
+	Building          bool    `json:"building"`
+	Duration          float64 `json:"duration"`
+	EstimatedDuration float64 `json:"estimatedDuration"`
+	// null while the build runs
+	Result      interface{}      `json:"result"`
+	Timestamp   float64          `json:"timestamp"`
+	Url         string           `json:"url"`
+	BuiltOn     string           `json:"builtOn"`
+	Actions     []actionResponse `json:"actions"`
+	KeepLog     bool             `json:"keepLog"`
+	Description string           `json:"description"`
+	// freestyle builds report one change set, pipelines a list of them
+	ChangeSet     *changeSetResponse  `json:"changeSet"`
+	ChangeSets    []changeSetResponse `json:"changeSets"`
+	PreviousBuild *buildRef           `json:"previousBuild"`
+	NextBuild     *buildRef           `json:"nextBuild"`
+}
+
+// infoTree asks for exactly the fields jobResponse reads rather than every
+// build of the job
+const infoTree string = "name,fullName,description,url,buildable,inQueue," +
+	"lastBuild[number,url,timestamp],lastStableBuild[number,url],lastSuccessfulBuild[number,url]," +
+	"lastFailedBuild[number,url],queueItem[id,stuck],color,healthReport[score,description]"
+
+// describeTree asks for exactly the fields buildResponse reads, leaving out
+// the bulk of a build's actions
+const describeTree string = "fullDisplayName,number,artifacts[displayPath,relativePath]," +
+	"building,duration,estimatedDuration,result,timestamp,url,builtOn,keepLog,description," +
+	"actions[_class,parameters[_class,name,value],causes[_class,shortDescription,userId,userName]," +
+	"lastBuiltRevision[SHA1,branch[name]],remoteUrls]," +
+	"changeSet[" + changeItemsTree + "],changeSets[" + changeItemsTree + "]," +
+	"previousBuild[number],nextBuild[number]"
+
+const changeItemsTree string = "items[commitId,id,revision,author[fullName],msg,affectedPaths,timestamp]"
+
+type actionResponse struct {
+	Class      string `json:"_class"`
+	Parameters []struct {
+		Class string      `json:"_class"`
+		Name  string      `json:"name"`
+		Value interface{} `json:"value"`
+	} `json:"parameters"`
+	Causes []struct {
+		Class            string `json:"_class"`
+		ShortDescription string `json:"shortDescription"`
+		UserID           string `json:"userId"`
+		UserName         string `json:"userName"`
+	} `json:"causes"`
+	// set on the Git plugin's BuildData
+	LastBuiltRevision *struct {
+		SHA1   string `json:"SHA1"`
+		Branch []struct {
+			Name string `json:"name"`
+		} `json:"branch"`
+	} `json:"lastBuiltRevision"`
+	RemoteUrls []string `json:"remoteUrls"`
+}
+
+// getChecked fetches the job's api/json, or that of the build when build, a
+// number or permalink, is set, limited to tree unless it is empty, warns about
+// unexpected fields and decodes it into v
+func (self *Client) getChecked(ctx context.Context, name, build, tree string, fields []expectedField, v interface{}) error {
+	nameAndID, what := jobPath(name), "job "+name
+	if build != "" {
+		nameAndID, what = path.Join(nameAndID, build), "build "+name
+	}
+	theurl := self.url(nameAndID, "api", "json")
+	if tree != "" {
+		theurl += "?tree=" + url.QueryEscape(tree)
+	}
+	ctx, cancel := self.requestContext(ctx)
+	defer cancel()
+	body, err := self.getRemote(ctx, theurl)
+	if err != nil {
+		return err
+	}
+	data, err := io.ReadAll(body)
+	body.Close()
+	if err != nil {
+		return err
+	}
+	raw := map[string]interface{}{}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	self.checkFields(what, raw, fields)
+	return skipTypeErrors(json.Unmarshal(data, v))
+}
+
+// a field of the wrong type is left zero rather than failing the whole
+// document, as checkFields has already reported it
+func skipTypeErrors(err error) error {
+	if _, ok := err.(*json.UnmarshalTypeError); ok {
+		return nil
+	}
+	return err
+}
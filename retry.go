@@ -0,0 +1,80 @@
+package jenkins
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"syscall"
+	"time"
+)
+
+// DEFAULT_RETRY_BACKOFF spaces out retries when Client.RetryBackoff is unset
+var DEFAULT_RETRY_BACKOFF = Backoff{Initial: 500 * time.Millisecond, Max: 10 * time.Second}
+
+// IsRetryable reports whether a failed GET is worth repeating: the server was
+// unreachable or the connection broke, or a proxy answered 502, 503 or 504
+// while Jenkins restarts. Other statuses, and errors such as a redirect loop
+// or a malformed response, are final.
+func IsRetryable(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	var httpErr *HTTPError
+	if errors.As(err, &httpErr) {
+		switch httpErr.StatusCode {
+		case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+			return true
+		}
+		return false
+	}
+	// every error from sending a request is a *url.Error, whatever its cause
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		err = urlErr.Err
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr) || errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, syscall.ECONNRESET)
+}
+
+func (self *Client) retryable(err error) bool {
+	if self.Retryable != nil {
+		return self.Retryable(err)
+	}
+	return IsRetryable(err)
+}
+
+func (self *Client) retryBackoff() *Backoff {
+	backoff := self.RetryBackoff
+	if backoff.Initial <= 0 {
+		backoff = DEFAULT_RETRY_BACKOFF
+	}
+	backoff.Reset()
+	return &backoff
+}
+
+// getResponse sends a GET, repeating it up to Retries times while it fails
+// in a retryable way. POSTs are never retried since they may not be
+// idempotent.
+func (self *Client) getResponse(ctx context.Context, theurl string) (*http.Response, error) {
+	return self.getResponseFrom(ctx, theurl, 0)
+}
+
+// getResponseFrom is getResponse asking only for the bytes from offset on,
+// which the server may honour with a 206 or ignore with a full 200.
+func (self *Client) getResponseFrom(ctx context.Context, theurl string, offset int64) (*http.Response, error) {
+	backoff := self.retryBackoff()
+	for attempt := 0; ; attempt++ {
+		resp, err := self.getOnce(ctx, theurl, offset)
+		if err == nil || attempt >= self.Retries || ctx.Err() != nil || !self.retryable(err) {
+			return resp, err
+		}
+		self.logger().Print("Retrying ", theurl, ": ", err)
+		if err := self.sleep(ctx, backoff.Next()); err != nil {
+			return nil, err
+		}
+	}
+}
@@ -0,0 +1,96 @@
+package jenkins
+
+import (
+	"compress/gzip"
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"sync/atomic"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestIsRetryable(t *testing.T) {
+	sent := func(err error) error {
+		return &url.Error{Op: "Get", URL: "http://ci/api/json", Err: err}
+	}
+	tests := []struct {
+		err  error
+		want bool
+	}{
+		{&HTTPError{StatusCode: 502}, true},
+		{&HTTPError{StatusCode: 503}, true},
+		{&HTTPError{StatusCode: 504}, true},
+		{&HTTPError{StatusCode: 404}, false},
+		{&HTTPError{StatusCode: 500}, false},
+		{sent(&net.OpError{Op: "dial", Net: "tcp", Err: syscall.ECONNREFUSED}), true},
+		{sent(io.EOF), true},
+		{io.ErrUnexpectedEOF, true},
+		{syscall.ECONNRESET, true},
+		{sent(errors.New("stopped after 5 redirects")), false},
+		{errors.New("authorize hook failed"), false},
+		{gzip.ErrHeader, false},
+		{sent(context.Canceled), false},
+		{context.DeadlineExceeded, false},
+		{nil, false},
+	}
+	for _, test := range tests {
+		if got := IsRetryable(test.err); got != test.want {
+			t.Errorf("IsRetryable(%v) = %v, want %v", test.err, got, test.want)
+		}
+	}
+}
+
+// flakyHandler answers 502 failures times before serving the job
+func flakyHandler(failures int32, requests *int32) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(requests, 1) <= failures {
+			http.Error(w, "Jenkins is restarting", http.StatusBadGateway)
+			return
+		}
+		writeJSON(w, map[string]interface{}{"name": "j"})
+	}
+}
+
+func TestRetriesRecoverFromBadGateway(t *testing.T) {
+	var requests int32
+	client := newTestClient(t, flakyHandler(2, &requests))
+	client.Retries = 2
+	client.RetryBackoff = Backoff{Initial: time.Second, Max: 4 * time.Second}
+	waits := []time.Duration{}
+	client.Sleep = func(ctx context.Context, d time.Duration) error {
+		waits = append(waits, d)
+		return nil
+	}
+
+	info, err := client.GetInfo("j")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Name != "j" || requests != 3 {
+		t.Errorf("got %q after %d requests, want j after 3", info.Name, requests)
+	}
+	if len(waits) != 2 || waits[0] != time.Second || waits[1] != 2*time.Second {
+		t.Errorf("waited %v between retries, want [1s 2s]", waits)
+	}
+}
+
+func TestRetriesGiveUp(t *testing.T) {
+	var requests int32
+	client := newTestClient(t, flakyHandler(2, &requests))
+	client.Retries = 1
+	if _, err := client.GetInfo("j"); !isStatus(err, http.StatusBadGateway) || requests != 2 {
+		t.Errorf("got %v after %d requests, want a 502 after 2", err, requests)
+	}
+
+	requests = 0
+	client.Retries = 5
+	client.Retryable = func(err error) bool { return false }
+	if _, err := client.GetInfo("j"); err == nil || requests != 1 {
+		t.Errorf("got %v after %d requests, want a single failed request", err, requests)
+	}
+}
@@ -0,0 +1,104 @@
+package jenkins
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"path"
+	"strconv"
+)
+
+// returned by GetTestResults for builds that published no test report
+var ErrNoTestReport = errors.New("build has no test report")
+
+const testCasesTree string = "suites[cases[className,name,status,errorDetails,errorStackTrace]]"
+
+const testReportTree string = "totalCount,passCount,failCount,skipCount," + testCasesTree +
+	",childReports[result[" + testCasesTree + "]]"
+
+type TestResults struct {
+	Total    int
+	Passed   int
+	Failed   int
+	Skipped  int
+	Failures []TestFailure
+}
+
+type TestFailure struct {
+	// the class name and test name joined with a dot
+	Name         string
+	ErrorDetails string
+	StackTrace   string
+}
+
+type testSuitesResponse struct {
+	Suites []struct {
+		Cases []struct {
+			ClassName       string `json:"className"`
+			Name            string `json:"name"`
+			Status          string `json:"status"`
+			ErrorDetails    string `json:"errorDetails"`
+			ErrorStackTrace string `json:"errorStackTrace"`
+		} `json:"cases"`
+	} `json:"suites"`
+}
+
+type testReportResponse struct {
+	testSuitesResponse
+	TotalCount int `json:"totalCount"`
+	PassCount  int `json:"passCount"`
+	FailCount  int `json:"failCount"`
+	SkipCount  int `json:"skipCount"`
+	// set instead of suites for matrix and multi-module builds
+	ChildReports []struct {
+		Result testSuitesResponse `json:"result"`
+	} `json:"childReports"`
+}
+
+// GetTestResults summarizes the build's JUnit-style test report, listing each
+// failing test.
+func (self *Client) GetTestResults(name string, id int) (*TestResults, error) {
+	id, err := self.sanitizeID(context.Background(), name, id)
+	if err != nil {
+		return nil, err
+	}
+	nameAndID := path.Join(jobPath(name), strconv.Itoa(id))
+	theurl := self.url(nameAndID, "testReport", "api", "json") + "?tree=" + url.QueryEscape(testReportTree)
+	resp := testReportResponse{}
+	err = skipTypeErrors(self.decodeJSON(context.Background(), theurl, &resp))
+	if isNotFound(err) {
+		return nil, ErrNoTestReport
+	} else if err != nil {
+		return nil, err
+	}
+	results := TestResults{}
+	results.Failed = resp.FailCount
+	results.Skipped = resp.SkipCount
+	results.Total = resp.TotalCount
+	if results.Total == 0 {
+		results.Total = resp.PassCount + resp.FailCount + resp.SkipCount
+	}
+	results.Passed = results.Total - results.Failed - results.Skipped
+	results.Failures = resp.failures()
+	for _, child := range resp.ChildReports {
+		results.Failures = append(results.Failures, child.Result.failures()...)
+	}
+	return &results, nil
+}
+
+func (self *testSuitesResponse) failures() []TestFailure {
+	failures := []TestFailure{}
+	for _, suite := range self.Suites {
+		for _, testCase := range suite.Cases {
+			if testCase.Status != "FAILED" && testCase.Status != "REGRESSION" {
+				continue
+			}
+			failure := TestFailure{}
+			failure.Name = testCase.ClassName + "." + testCase.Name
+			failure.ErrorDetails = testCase.ErrorDetails
+			failure.StackTrace = testCase.ErrorStackTrace
+			failures = append(failures, failure)
+		}
+	}
+	return failures
+}
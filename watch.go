@@ -0,0 +1,53 @@
+package jenkins
+
+import (
+	"sync"
+	"time"
+)
+
+// WatchLastBuild polls the job every interval and sends the build info each
+// time its last build number increases; a non-positive interval means
+// DEFAULT_POLL_INTERVAL. Builds that already exist when the watch starts are
+// not sent. Calling the returned function stops the watch and
+// closes the channel.
+func (self *Client) WatchLastBuild(name string, interval time.Duration) (<-chan JenkinsBuildInfo, func()) {
+	if interval <= 0 {
+		interval = DEFAULT_POLL_INTERVAL
+	}
+	builds := make(chan JenkinsBuildInfo)
+	done := make(chan struct{})
+	var once sync.Once
+	stop := func() {
+		once.Do(func() { close(done) })
+	}
+	go func() {
+		defer close(builds)
+		lastSeen := -1
+		for {
+			info, err := self.GetInfo(name)
+			if err != nil {
+				self.logger().Print("Watch of ", name, " failed to poll: ", err)
+			} else if lastSeen == -1 {
+				lastSeen = info.LastBuild
+			} else if info.LastBuild > lastSeen {
+				binfo, err := self.GetBuildInfo(name, info.LastBuild)
+				if err != nil {
+					self.logger().Print("Watch of ", name, " failed to fetch build #", info.LastBuild, ": ", err)
+				} else {
+					lastSeen = info.LastBuild
+					select {
+					case builds <- *binfo:
+					case <-done:
+						return
+					}
+				}
+			}
+			select {
+			case <-time.After(interval):
+			case <-done:
+				return
+			}
+		}
+	}()
+	return builds, stop
+}
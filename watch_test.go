@@ -0,0 +1,23 @@
+package jenkins
+
+import (
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestWatchLastBuildDefaultsInterval(t *testing.T) {
+	var polls int32
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&polls, 1)
+		writeJSON(w, map[string]interface{}{"name": "j", "lastBuild": map[string]interface{}{"number": 1}})
+	}))
+
+	_, stop := client.WatchLastBuild("j", 0)
+	time.Sleep(200 * time.Millisecond)
+	stop()
+	if n := atomic.LoadInt32(&polls); n > 1 {
+		t.Errorf("polled %d times in 200ms with a zero interval", n)
+	}
+}